@@ -0,0 +1,219 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BodyFunc is a request body factory, usable as the body of Post, Put & Patch.
+// It is called for every attempt, so requests with it can be retried.
+// If the returned io.Reader is an io.Closer, it is closed once sent.
+//
+//	body := rest.BodyFunc(func() (io.Reader, error) {
+//		return os.Open("export.csv")
+//	})
+//
+// Plain io.Reader bodies are also streamed, but they can only be retried
+// if they are an io.Seeker. Either way, the body is sent as is, without
+// marshalling, with the RequestBuilder media type as Content-Type.
+// Content-Length is set when known, otherwise the body is chunked.
+type BodyFunc func() (io.Reader, error)
+
+// requestBody is the body of a request, which can be read again to retry it
+type requestBody struct {
+	getBody func() (io.ReadCloser, error)
+
+	// -1 if unknown
+	contentLength int64
+
+	// Overrides the RequestBuilder media type
+	contentType string
+
+	// Sent only once, as it can't be read again
+	once bool
+}
+
+// newRequestBody marshals the body, or prepares it to be streamed
+func (rb *RequestBuilder) newRequestBody(body interface{}) (*requestBody, error) {
+
+	switch b := body.(type) {
+	case *Multipart:
+		return &requestBody{getBody: b.open, contentLength: -1, contentType: b.ContentType()}, nil
+	case BodyFunc:
+		return newStreamBody(b)
+	case func() (io.Reader, error):
+		return newStreamBody(b)
+	case io.Reader:
+		open, seekable := readerBody(b)
+		body, err := newStreamBody(open)
+		if err != nil {
+			return nil, err
+		}
+		body.once = !seekable
+		return body, nil
+	}
+
+	b, err := rb.marshalReqBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &requestBody{
+		getBody: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		},
+		contentLength: int64(len(b)),
+	}, nil
+}
+
+// newStreamBody opens the body once to find out its length,
+// and hands that reader to the first attempt.
+func newStreamBody(open BodyFunc) (*requestBody, error) {
+
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	length := readerLen(r)
+	first := toReadCloser(r)
+
+	if length == 0 {
+		first.Close()
+		return &requestBody{}, nil
+	}
+
+	var mutex sync.Mutex
+
+	getBody := func() (io.ReadCloser, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if first != nil {
+			rc := first
+			first = nil
+			return rc, nil
+		}
+
+		r, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return toReadCloser(r), nil
+	}
+
+	return &requestBody{getBody: getBody, contentLength: length}, nil
+}
+
+// readerBody reads r, over and over again if it is an io.Seeker,
+// which is reported by seekable. r is never closed, it belongs to the caller.
+func readerBody(r io.Reader) (open BodyFunc, seekable bool) {
+
+	seeker, seekable := r.(io.Seeker)
+
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	var mutex sync.Mutex
+	read := false
+
+	open = func() (io.Reader, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if read {
+			if !seekable {
+				return nil, errors.New("body reader can't be read twice, use a BodyFunc to retry it")
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		read = true
+		return nopCloser{r}, nil
+	}
+
+	return open, seekable
+}
+
+// nopCloser keeps the caller reader open, while letting readerLen see it
+type nopCloser struct {
+	io.Reader
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func (c nopCloser) Unwrap() io.Reader {
+	return c.Reader
+}
+
+// readerLen returns how many bytes are left in r, or -1 if unknown
+func readerLen(r io.Reader) int64 {
+
+	switch v := r.(type) {
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *bytes.Reader:
+		return int64(v.Len())
+	case *strings.Reader:
+		return int64(v.Len())
+	case *os.File:
+		fi, err := v.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return fi.Size() - offset
+	case interface{ Unwrap() io.Reader }:
+		return readerLen(v.Unwrap())
+	}
+
+	return -1
+}
+
+func toReadCloser(r io.Reader) io.ReadCloser {
+	if rc, ok := r.(io.ReadCloser); ok {
+		return rc
+	}
+	return ioutil.NopCloser(r)
+}
+
+func (b *requestBody) setTo(req *http.Request) error {
+
+	if b.contentLength == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+		return nil
+	}
+
+	body, err := b.getBody()
+	if err != nil {
+		return err
+	}
+
+	req.Body = body
+	req.ContentLength = b.contentLength
+
+	if !b.once {
+		req.GetBody = b.getBody
+	}
+
+	return nil
+}
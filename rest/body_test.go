@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newEchoServer fails the first failures requests with a 503, and then
+// echoes the request body length, transfer encoding & content.
+func newEchoServer(t *testing.T, failures int32) *httptest.Server {
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintf(w, "%d %v %s", req.ContentLength, req.TransferEncoding, body)
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReaderBody(t *testing.T) {
+
+	server := newEchoServer(t, 0)
+	builder := RequestBuilder{ContentType: BYTES}
+
+	tests := []struct {
+		body     io.Reader
+		expected string
+	}{
+		{strings.NewReader("hello"), "5 [] hello"},
+		{bytes.NewBufferString("hello"), "5 [] hello"},
+		{io.MultiReader(strings.NewReader("hel"), strings.NewReader("lo")), "-1 [chunked] hello"},
+		{strings.NewReader(""), "0 [] "},
+	}
+
+	for _, test := range tests {
+		if r := builder.Post(server.URL, test.body); r.Err != nil || r.String() != test.expected {
+			t.Fatalf("Expected %q, got %q, error: %v", test.expected, r.String(), r.Err)
+		}
+	}
+}
+
+func TestReaderBodyRetry(t *testing.T) {
+
+	policy := &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, RetryNonIdempotent: true}
+
+	// Seekable readers are rewound
+	builder := RequestBuilder{ContentType: BYTES, RetryPolicy: policy}
+	r := builder.Post(newEchoServer(t, 1).URL, strings.NewReader("hello"))
+	if r.Err != nil || r.String() != "5 [] hello" || r.Attempts() != 2 {
+		t.Fatalf("Unexpected response %q, attempts: %d, error: %v", r.String(), r.Attempts(), r.Err)
+	}
+
+	// Others can't be read twice, so the failed response is kept
+	body := io.MultiReader(strings.NewReader("hello"))
+	r = builder.Post(newEchoServer(t, 1).URL, body)
+	if r.Err != nil || r.StatusCode != http.StatusServiceUnavailable || r.Attempts() != 1 {
+		t.Fatalf("Unexpected response %q, attempts: %d, error: %v", r.String(), r.Attempts(), r.Err)
+	}
+
+	// Factories are called once per attempt
+	var opened int32
+	factory := BodyFunc(func() (io.Reader, error) {
+		atomic.AddInt32(&opened, 1)
+		return ioutil.NopCloser(io.MultiReader(strings.NewReader("hello"))), nil
+	})
+
+	r = builder.Post(newEchoServer(t, 1).URL, factory)
+	if r.Err != nil || r.String() != "-1 [chunked] hello" || opened != 2 {
+		t.Fatalf("Unexpected response %q, opened: %d, error: %v", r.String(), opened, r.Err)
+	}
+}
+
+type closeCounter struct {
+	io.Reader
+	closed *int32
+}
+
+func (c closeCounter) Close() error {
+	atomic.AddInt32(c.closed, 1)
+	return nil
+}
+
+func TestBodyFuncInvalidURL(t *testing.T) {
+
+	var opened, closed int32
+	factory := BodyFunc(func() (io.Reader, error) {
+		atomic.AddInt32(&opened, 1)
+		return closeCounter{strings.NewReader("hello"), &closed}, nil
+	})
+
+	builder := RequestBuilder{ContentType: BYTES}
+	if r := builder.Post("http://[::1", factory); r.Err == nil {
+		t.Fatal("An invalid URL should get an error")
+	}
+
+	if opened != closed {
+		t.Fatalf("Opened %d bodies, but closed %d", opened, closed)
+	}
+}
@@ -47,6 +47,7 @@ const (
 
 type lruMsg struct {
 	operation lruOperation
+	key       string
 	resp      *Response
 }
 
@@ -85,7 +86,7 @@ func (rCache *resourceTTLLRUMap) lruOperations() {
 		case move:
 			rCache.lruList.MoveToFront(msg.resp.listElement)
 		case push:
-			msg.resp.listElement = rCache.lruList.PushFront(msg.resp.Request.URL.String())
+			msg.resp.listElement = rCache.lruList.PushFront(msg.key)
 		case del:
 			rCache.lruList.Remove(msg.resp.listElement)
 		case last:
@@ -99,10 +100,11 @@ func (rCache *resourceTTLLRUMap) get(key string) *Response {
 	//Read lock only
 	rCache.rwMutex.RLock()
 	resp := rCache.cache[key]
+	expired := resp != nil && resp.ttl != nil && resp.ttl.Sub(time.Now()) <= 0
 	rCache.rwMutex.RUnlock()
 
 	//If expired, remove it
-	if resp != nil && resp.ttl != nil && resp.ttl.Sub(time.Now()) <= 0 {
+	if expired {
 
 		//Full lock
 		rCache.rwMutex.Lock()
@@ -140,44 +142,106 @@ func (rCache *resourceTTLLRUMap) setNX(key string, value *Response) {
 	rCache.rwMutex.Lock()
 	defer rCache.rwMutex.Unlock()
 
-	v := rCache.cache[key]
+	if rCache.cache[key] == nil {
+		rCache.insert(key, value)
+	}
 
-	if v == nil {
+}
 
-		rCache.cache[key] = value
+// Set, replacing any previous value (ie: a stale response that
+// failed revalidation)
+func (rCache *resourceTTLLRUMap) set(key string, value *Response) {
 
-		//PushFront in LruList
-		rCache.lruChan <- &lruMsg{
-			operation: push,
-			resp:      value,
-		}
+	//Full Lock
+	rCache.rwMutex.Lock()
+	defer rCache.rwMutex.Unlock()
 
-		//Set ttl if necesary
-		if value.ttl != nil {
-			value.skipListElement = rCache.skipList.insert(key, *value.ttl)
-			rCache.ttlChan <- true
-		}
+	if v := rCache.cache[key]; v != nil {
+		rCache.remove(key, v)
+	}
 
-		// Add Response Size to Cache
-		// Not necessary to use atomic
-		cacheSize += value.size()
+	rCache.insert(key, value)
+}
 
-		for i := 0; ByteSize(cacheSize) >= MaxCacheSize && i < 10; i++ {
+// Refresh replaces a cached response with a copy updated with the caching
+// headers of a 304 (Not Modified) response, and returns that copy.
+// The cached response is never modified, as other requests may be reading it.
+func (rCache *resourceTTLLRUMap) refresh(key string, value *Response, notModified *Response) *Response {
+
+	fresh := &Response{
+		Response:     value.Response,
+		byteBody:     value.byteBody,
+		ttl:          value.ttl,
+		lastModified: value.lastModified,
+		etag:         value.etag,
+		revalidate:   value.revalidate,
+	}
 
-			rCache.lruChan <- &lruMsg{
-				last,
-				nil,
-			}
+	if setETag(notModified) {
+		fresh.etag = notModified.etag
+	}
 
-			k := <-rCache.popChan
-			r := rCache.cache[k]
+	if setLastModified(notModified) {
+		fresh.lastModified = notModified.lastModified
+	}
 
-			rCache.remove(k, r)
+	if setTTL(notModified) {
+		fresh.ttl = notModified.ttl
+		fresh.revalidate = false
+	}
 
-		}
+	//Full Lock
+	rCache.rwMutex.Lock()
+	defer rCache.rwMutex.Unlock()
 
+	// Unless it got evicted or replaced while revalidating
+	if rCache.cache[key] == value {
+		rCache.remove(key, value)
+		rCache.insert(key, fresh)
 	}
 
+	return fresh
+}
+
+// Insert a new value. Full lock must be held by the caller
+func (rCache *resourceTTLLRUMap) insert(key string, value *Response) {
+
+	// It would evict the whole cache
+	if ByteSize(value.size()) >= MaxCacheSize {
+		return
+	}
+
+	rCache.cache[key] = value
+
+	//PushFront in LruList
+	rCache.lruChan <- &lruMsg{
+		operation: push,
+		key:       key,
+		resp:      value,
+	}
+
+	//Set ttl if necesary
+	if value.ttl != nil {
+		value.skipListElement = rCache.skipList.insert(key, *value.ttl)
+		rCache.ttlChan <- true
+	}
+
+	// Add Response Size to Cache
+	// Not necessary to use atomic
+	cacheSize += value.size()
+
+	for i := 0; ByteSize(cacheSize) >= MaxCacheSize && i < 10; i++ {
+
+		rCache.lruChan <- &lruMsg{
+			operation: last,
+		}
+
+		k := <-rCache.popChan
+		r := rCache.cache[k]
+
+		rCache.remove(k, r)
+
+	}
 }
 
 //
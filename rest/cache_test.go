@@ -2,7 +2,9 @@ package rest
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -151,3 +153,165 @@ func TestCacheSlowForkJoinGet(t *testing.T) {
 	}
 
 }
+
+func TestCacheHit(t *testing.T) {
+
+	rb.Get("/cache/user")
+	resp := rb.Get("/cache/user")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if !resp.CacheHit() {
+		t.Fatal("Response should be a cache hit")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:      server.URL,
+		DisableCache: true,
+	}
+
+	builder.Get("/cache/user")
+	resp := builder.Get("/cache/user")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if resp.CacheHit() {
+		t.Fatal("Response should not be a cache hit")
+	}
+}
+
+func TestCacheRevalidateEtag(t *testing.T) {
+
+	rb.Get("/cache/etag/user")
+	resp := rb.Get("/cache/etag/user")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if !resp.CacheHit() {
+		t.Fatal("A 304 (Not Modified) should be served from cache")
+	}
+}
+
+func TestCacheRevalidateLastModified(t *testing.T) {
+
+	rb.Get("/cache/lastmodified/user")
+	resp := rb.Get("/cache/lastmodified/user")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if !resp.CacheHit() {
+		t.Fatal("A 304 (Not Modified) should be served from cache")
+	}
+}
+
+func TestCacheHitDoesNotMutateCachedResponse(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := RequestBuilder{BaseURL: server.URL}
+
+	first := client.Get("/")
+	hit := client.Get("/")
+
+	if !hit.CacheHit() {
+		t.Fatal("Response should be a cache hit")
+	}
+
+	if first.CacheHit() {
+		t.Fatal("First response should not become a cache hit")
+	}
+}
+
+func TestCacheSkipsPrivateAndAuthorized(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, _, _ := req.BasicAuth()
+
+		switch req.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+
+		w.Write([]byte(user))
+	}))
+	defer server.Close()
+
+	alice := RequestBuilder{BaseURL: server.URL, BasicAuth: &BasicAuth{UserName: "alice", Password: "a"}}
+	bob := RequestBuilder{BaseURL: server.URL, BasicAuth: &BasicAuth{UserName: "bob", Password: "b"}}
+
+	for _, path := range []string{"/private", "/shared"} {
+		alice.Get(path)
+
+		if resp := bob.Get(path); resp.CacheHit() || resp.String() != "bob" {
+			t.Fatalf("%s: bob got %q from the cache", path, resp.String())
+		}
+	}
+
+	anonymous := RequestBuilder{BaseURL: server.URL}
+
+	for _, path := range []string{"/private", "/no-store", "/vary"} {
+		anonymous.Get(path)
+
+		if resp := anonymous.Get(path); resp.CacheHit() {
+			t.Fatalf("%s: response should not be cached", path)
+		}
+	}
+}
+
+func TestCacheConcurrentRevalidation(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("ETag", "v1")
+
+		if req.Header.Get("If-None-Match") == "v1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			client := RequestBuilder{BaseURL: server.URL}
+
+			for j := 0; j < 20; j++ {
+				if r := client.Get("/etag"); r.Err != nil || r.String() != "ok" {
+					t.Errorf("Unexpected response %q, error: %v", r.String(), r.Err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,362 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CassetteMode defines if a Cassette records or replays interactions
+type CassetteMode int
+
+const (
+	// CassetteReplay loads the cassette file as mocks
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord proxies unmatched mockup requests to the real target,
+	// and saves them to the cassette file
+	CassetteRecord
+)
+
+// DefaultRedactHeaders are the headers redacted when RedactHeaders is not set.
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// RedactedValue replaces the value of redacted headers in cassette files.
+const RedactedValue = "REDACTED"
+
+// Cassette records real request/response pairs to a file, to be replayed
+// later as mocks, so tests can run offline.
+//
+//	rest.UseCassette(&rest.Cassette{Path: "testdata/users.json", Mode: rest.CassetteRecord})
+//	defer rest.EjectCassette()
+type Cassette struct {
+
+	// Cassette file path
+	Path string
+
+	// Record or Replay. Default: CassetteReplay
+	Mode CassetteMode
+
+	// Headers whose values are not saved in the cassette file.
+	// Default: DefaultRedactHeaders
+	RedactHeaders []string
+
+	// Request headers a request must have to match a replayed interaction.
+	// By default only the method & URL are matched.
+	MatchHeaders []string
+
+	// Require the request body to match the recorded one when replaying.
+	// JSON bodies are compared semantically.
+	MatchBody bool
+
+	mutex        sync.Mutex
+	interactions []CassetteInteraction
+	mocks        []*Mock
+}
+
+// CassetteInteraction is a recorded request/response pair
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a recorded request
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+type cassetteFile struct {
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// Headers not worth recording nor forwarding
+var cassetteSkipHeaders = []string{
+	"X-Original-Url", "Accept-Encoding", "Connection", "Content-Length", "Transfer-Encoding",
+}
+
+// UseCassette starts the mockup server with the given cassette.
+//
+// In CassetteReplay mode the cassette file is loaded as mocks, several
+// interactions for the same request are replayed in order.
+// In CassetteRecord mode requests not matching a mock are sent to the real target,
+// and recorded until EjectCassette is called.
+func UseCassette(c *Cassette) error {
+
+	if err := mockServer.UseCassette(c); err != nil {
+		return err
+	}
+
+	StartMockupServer()
+	return nil
+}
+
+// EjectCassette saves the recorded interactions, removes the replayed mocks
+// and stops the mockup server.
+func EjectCassette() error {
+
+	if !mockServer.hasCassette() {
+		return nil
+	}
+
+	StopMockupServer()
+	return mockServer.EjectCassette()
+}
+
+// UseCassette sets the cassette of the mock server, see the package-level UseCassette.
+func (ms *MockServer) UseCassette(c *Cassette) error {
+
+	if ms.hasCassette() {
+		return errors.New("cassette already in use")
+	}
+
+	if c.Mode == CassetteReplay {
+		if err := c.load(); err != nil {
+			return err
+		}
+	}
+
+	ms.mutex.Lock()
+	if ms.cassette != nil {
+		ms.mutex.Unlock()
+		return errors.New("cassette already in use")
+	}
+	ms.cassette = c
+	ms.mutex.Unlock()
+
+	return ms.AddMockups(c.mocks...)
+}
+
+// EjectCassette saves the recorded interactions, and removes the replayed mocks.
+func (ms *MockServer) EjectCassette() error {
+
+	ms.mutex.Lock()
+	c := ms.cassette
+	ms.cassette = nil
+	ms.mutex.Unlock()
+
+	if c == nil {
+		return nil
+	}
+
+	if c.Mode == CassetteRecord {
+		return c.save()
+	}
+
+	ms.removeMockups(c.mocks...)
+	return nil
+}
+
+func (ms *MockServer) hasCassette() bool {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	return ms.cassette != nil
+}
+
+// Interactions returns the recorded, or loaded, interactions.
+func (c *Cassette) Interactions() []CassetteInteraction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]CassetteInteraction(nil), c.interactions...)
+}
+
+// record sends the request to the real target, writes its response
+// and saves the interaction
+func (c *Cassette) record(writer http.ResponseWriter, call MockCall) {
+
+	req, err := http.NewRequest(call.Method, call.URL, bytes.NewReader(call.Body))
+	if err != nil {
+		writer.WriteHeader(http.StatusBadGateway)
+		writer.Write([]byte(err.Error()))
+		return
+	}
+
+	req.Header = cleanHeader(call.Header)
+
+	client := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadGateway)
+		writer.Write([]byte(err.Error()))
+		return
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadGateway)
+		writer.Write([]byte(err.Error()))
+		return
+	}
+
+	header := cleanHeader(resp.Header)
+
+	for k, v := range header {
+		for _, vv := range v {
+			writer.Header().Add(k, vv)
+		}
+	}
+
+	writer.WriteHeader(resp.StatusCode)
+	writer.Write(body)
+
+	c.mutex.Lock()
+	c.interactions = append(c.interactions, CassetteInteraction{
+		Request: CassetteRequest{
+			Method: call.Method,
+			URL:    call.URL,
+			Header: c.redact(req.Header),
+			Body:   string(call.Body),
+		},
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     c.redact(header),
+			Body:       string(body),
+		},
+	})
+	c.mutex.Unlock()
+}
+
+func (c *Cassette) save() error {
+
+	c.mutex.Lock()
+	b, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	c.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.Path, b, 0644)
+}
+
+// load reads the cassette file and builds its mocks
+func (c *Cassette) load() error {
+
+	b, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+
+	var f cassetteFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("Error parsing cassette %s. Cause: %s", c.Path, err.Error())
+	}
+
+	mocks := make(map[string]*Mock)
+	var ordered []*Mock
+
+	for _, i := range f.Interactions {
+
+		m := &Mock{
+			URL:        i.Request.URL,
+			HTTPMethod: i.Request.Method,
+		}
+
+		for _, h := range c.MatchHeaders {
+			if values := i.Request.Header.Values(h); len(values) > 0 {
+				if m.ReqHeaders == nil {
+					m.ReqHeaders = make(http.Header)
+				}
+				m.ReqHeaders[http.CanonicalHeaderKey(h)] = values
+			}
+		}
+
+		if c.MatchBody && i.Request.Body != "" {
+			m.ReqBody = i.Request.Body
+			if json.Valid([]byte(i.Request.Body)) {
+				m.ReqBodyMatch = BodyJSON
+			}
+		}
+
+		// Same request, replay the responses in order
+		key := mockKey(m)
+		if prev := mocks[key]; prev != nil {
+			m = prev
+		} else {
+			mocks[key] = m
+			ordered = append(ordered, m)
+		}
+
+		m.Responses = append(m.Responses, MockResponse{
+			HTTPCode: i.Response.StatusCode,
+			Headers:  i.Response.Header,
+			Body:     i.Response.Body,
+		})
+	}
+
+	c.mutex.Lock()
+	c.interactions = f.Interactions
+	c.mocks = ordered
+	c.mutex.Unlock()
+
+	return nil
+}
+
+func (c *Cassette) redact(h http.Header) http.Header {
+
+	redactHeaders := c.RedactHeaders
+	if redactHeaders == nil {
+		redactHeaders = DefaultRedactHeaders
+	}
+
+	h = h.Clone()
+	for _, k := range redactHeaders {
+		if values := h.Values(k); len(values) > 0 {
+			redacted := make([]string, len(values))
+			for i := range redacted {
+				redacted[i] = RedactedValue
+			}
+			h[http.CanonicalHeaderKey(k)] = redacted
+		}
+	}
+
+	return h
+}
+
+func cleanHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range cassetteSkipHeaders {
+		h.Del(k)
+	}
+	return h
+}
+
+// mockKey identifies the request a mock matches
+func mockKey(m *Mock) string {
+
+	keys := make([]string, 0, len(m.ReqHeaders))
+	for k, v := range m.ReqHeaders {
+		keys = append(keys, k+":"+strings.Join(v, ","))
+	}
+	sort.Strings(keys)
+
+	return m.HTTPMethod + " " + m.URL + " " + strings.Join(keys, ";") + " " + m.ReqBody
+}
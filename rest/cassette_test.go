@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+
+	target := httptest.NewServer(tmux)
+	path := filepath.Join(t.TempDir(), "cassettes", "users.json")
+
+	builder := RequestBuilder{
+		BaseURL:   target.URL,
+		BasicAuth: &BasicAuth{UserName: "user", Password: "secret"},
+	}
+
+	// Record
+	if err := UseCassette(&Cassette{Path: path, Mode: CassetteRecord}); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := builder.Get("/user")
+	created := builder.Post("/user", &User{Name: "Matilda"})
+
+	if err := EjectCassette(); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded.StatusCode != http.StatusOK || created.StatusCode != http.StatusCreated {
+		t.Fatal("Recorded requests should reach the real target")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), "Basic ") || !strings.Contains(string(b), RedactedValue) {
+		t.Fatal("Authorization header should be redacted")
+	}
+
+	// Replay, offline
+	target.Close()
+
+	if err := UseCassette(&Cassette{Path: path, MatchBody: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer EjectCassette()
+
+	replayed := builder.Get("/user")
+	if replayed.StatusCode != http.StatusOK || replayed.String() != recorded.String() {
+		t.Fatalf("Replayed response differs: %s", replayed.String())
+	}
+
+	if r := builder.Post("/user", &User{Name: "Matilda"}); r.StatusCode != http.StatusCreated {
+		t.Fatal("Status != Created (201)")
+	}
+
+	if r := builder.Post("/user", &User{Name: "Pichucha"}); r.String() != MockNotFoundError {
+		t.Fatal("Body should be matched when replaying")
+	}
+}
+
+func TestCassetteMissingFile(t *testing.T) {
+
+	err := UseCassette(&Cassette{Path: filepath.Join(t.TempDir(), "missing.json")})
+	if err == nil {
+		EjectCassette()
+		t.Fatal("Missing cassette should get an error")
+	}
+}
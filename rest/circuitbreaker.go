@@ -0,0 +1,327 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultCircuitCoolDown is the default time a circuit stays open
+// before letting a probe request through.
+var DefaultCircuitCoolDown = 5 * time.Second
+
+// DefaultCircuitConsecutiveFailures is the default number of consecutive failures
+// that opens a circuit, used when no threshold is set.
+var DefaultCircuitConsecutiveFailures = 5
+
+// CircuitState represents the state of a host circuit
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request without sending it
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitOpenError is the Response error when a request is short-circuited
+// because the circuit of its host is open.
+type CircuitOpenError struct {
+	Host  string
+	State CircuitState
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s for host %s", e.State, e.Host)
+}
+
+// CircuitBreaker keeps a circuit per host, so a failing dependency
+// does not get hammered with requests.
+//
+// A closed circuit opens when any of the thresholds is reached.
+// After CoolDown, it becomes half-open and lets HalfOpenRequests probes through:
+// if all of them succeed the circuit closes, if any fails it opens again.
+//
+// A CircuitBreaker is thread-safe and may be shared by many RequestBuilders.
+type CircuitBreaker struct {
+
+	// Open the circuit after this many consecutive failures.
+	// Default: DefaultCircuitConsecutiveFailures, if FailureRatio is not set either.
+	ConsecutiveFailures int
+
+	// Open the circuit when failures/requests reaches this ratio (0 to 1),
+	// once at least MinRequests have been made.
+	FailureRatio float64
+
+	// Minimum requests before FailureRatio is considered.
+	MinRequests int
+
+	// Clear closed circuit counts every Interval. Zero means never.
+	Interval time.Duration
+
+	// Time an open circuit waits before becoming half-open.
+	// Default: DefaultCircuitCoolDown
+	CoolDown time.Duration
+
+	// Probes let through while half-open. Default: 1
+	HalfOpenRequests int
+
+	// IsFailure let you decide if a response is a failure.
+	// Default: transport errors and 5xx responses.
+	IsFailure func(*Response) bool
+
+	// OnStateChange is called whenever a host circuit changes state.
+	OnStateChange func(host string, from CircuitState, to CircuitState)
+
+	mutex    sync.Mutex
+	circuits map[string]*hostCircuit
+}
+
+type hostCircuit struct {
+	state      CircuitState
+	generation uint64
+	expiry     time.Time
+
+	requests            int
+	failures            int
+	consecutiveFailures int
+	inFlight            int
+	successes           int
+}
+
+type stateChange struct {
+	from    CircuitState
+	to      CircuitState
+	changed bool
+}
+
+// State returns the current circuit state of a host.
+func (cb *CircuitBreaker) State(host string) CircuitState {
+
+	cb.mutex.Lock()
+	c, sc := cb.circuit(host, time.Now())
+	state := c.state
+	cb.mutex.Unlock()
+
+	cb.notify(host, sc)
+	return state
+}
+
+func (cb *CircuitBreaker) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) *Response {
+
+		host := originalHost(req)
+
+		generation, err := cb.allow(host)
+		if err != nil {
+			return &Response{Err: err}
+		}
+
+		resp := next(req)
+		cb.record(host, generation, resp)
+
+		return resp
+	}
+}
+
+func (cb *CircuitBreaker) allow(host string) (uint64, error) {
+
+	cb.mutex.Lock()
+
+	now := time.Now()
+	c, sc := cb.circuit(host, now)
+
+	var err error
+
+	switch {
+	case c.state == CircuitOpen:
+		err = &CircuitOpenError{Host: host, State: c.state, Until: c.expiry}
+	case c.state == CircuitHalfOpen && c.inFlight >= cb.halfOpenRequests():
+		err = &CircuitOpenError{Host: host, State: c.state}
+	default:
+		c.inFlight++
+		c.requests++
+	}
+
+	generation := c.generation
+	cb.mutex.Unlock()
+
+	cb.notify(host, sc)
+	return generation, err
+}
+
+func (cb *CircuitBreaker) record(host string, generation uint64, resp *Response) {
+
+	cb.mutex.Lock()
+
+	now := time.Now()
+	c, sc := cb.circuit(host, now)
+
+	// The circuit changed while the request was in flight
+	if c.generation != generation {
+		cb.mutex.Unlock()
+		cb.notify(host, sc)
+		return
+	}
+
+	c.inFlight--
+
+	// The caller gave up or refused a too large body, it says nothing about the host
+	var tooLarge *ResponseTooLargeError
+	if cb.IsFailure == nil && (errors.Is(resp.Err, context.Canceled) || errors.As(resp.Err, &tooLarge)) {
+		c.requests--
+		cb.mutex.Unlock()
+		cb.notify(host, sc)
+		return
+	}
+
+	failure := cb.isFailure(resp)
+
+	if failure {
+		c.failures++
+		c.consecutiveFailures++
+	} else {
+		c.successes++
+		c.consecutiveFailures = 0
+	}
+
+	var tsc stateChange
+
+	switch c.state {
+	case CircuitClosed:
+		if cb.trip(c) {
+			tsc = cb.setState(c, CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		if failure {
+			tsc = cb.setState(c, CircuitOpen, now)
+		} else if c.successes >= cb.halfOpenRequests() {
+			tsc = cb.setState(c, CircuitClosed, now)
+		}
+	}
+
+	cb.mutex.Unlock()
+
+	cb.notify(host, sc)
+	cb.notify(host, tsc)
+}
+
+// circuit returns the host circuit, moving it to half-open if its cool-down is over.
+// Lock must be held by the caller.
+func (cb *CircuitBreaker) circuit(host string, now time.Time) (*hostCircuit, stateChange) {
+
+	if cb.circuits == nil {
+		cb.circuits = make(map[string]*hostCircuit)
+	}
+
+	c := cb.circuits[host]
+	if c == nil {
+		c = &hostCircuit{expiry: cb.intervalExpiry(now)}
+		cb.circuits[host] = c
+	}
+
+	switch {
+	case c.state == CircuitOpen && !now.Before(c.expiry):
+		return c, cb.setState(c, CircuitHalfOpen, now)
+	case c.state == CircuitClosed && !c.expiry.IsZero() && !now.Before(c.expiry):
+		c.clear()
+		c.expiry = cb.intervalExpiry(now)
+	}
+
+	return c, stateChange{}
+}
+
+func (cb *CircuitBreaker) setState(c *hostCircuit, state CircuitState, now time.Time) stateChange {
+
+	sc := stateChange{from: c.state, to: state, changed: true}
+
+	c.state = state
+	c.generation++
+	c.inFlight = 0
+	c.clear()
+
+	switch state {
+	case CircuitOpen:
+		coolDown := cb.CoolDown
+		if coolDown <= 0 {
+			coolDown = DefaultCircuitCoolDown
+		}
+		c.expiry = now.Add(coolDown)
+	case CircuitClosed:
+		c.expiry = cb.intervalExpiry(now)
+	default:
+		c.expiry = time.Time{}
+	}
+
+	return sc
+}
+
+func (cb *CircuitBreaker) trip(c *hostCircuit) bool {
+
+	consecutiveFailures := cb.ConsecutiveFailures
+	if consecutiveFailures <= 0 && cb.FailureRatio <= 0 {
+		consecutiveFailures = DefaultCircuitConsecutiveFailures
+	}
+
+	if consecutiveFailures > 0 && c.consecutiveFailures >= consecutiveFailures {
+		return true
+	}
+
+	return cb.FailureRatio > 0 && c.requests >= cb.MinRequests &&
+		float64(c.failures)/float64(c.requests) >= cb.FailureRatio
+}
+
+func (cb *CircuitBreaker) notify(host string, sc stateChange) {
+	if sc.changed && cb.OnStateChange != nil {
+		cb.OnStateChange(host, sc.from, sc.to)
+	}
+}
+
+func (cb *CircuitBreaker) isFailure(resp *Response) bool {
+
+	if cb.IsFailure != nil {
+		return cb.IsFailure(resp)
+	}
+
+	return resp.Err != nil || resp.Response == nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+func (cb *CircuitBreaker) halfOpenRequests() int {
+	if cb.HalfOpenRequests > 0 {
+		return cb.HalfOpenRequests
+	}
+	return 1
+}
+
+func (cb *CircuitBreaker) intervalExpiry(now time.Time) time.Time {
+	if cb.Interval > 0 {
+		return now.Add(cb.Interval)
+	}
+	return time.Time{}
+}
+
+func (c *hostCircuit) clear() {
+	c.requests = 0
+	c.failures = 0
+	c.consecutiveFailures = 0
+	c.successes = 0
+}
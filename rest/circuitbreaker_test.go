@@ -0,0 +1,183 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpens(t *testing.T) {
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var transitions []string
+
+	cb := &CircuitBreaker{
+		ConsecutiveFailures: 3,
+		CoolDown:            50 * time.Millisecond,
+		OnStateChange: func(host string, from CircuitState, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	}
+
+	builder := RequestBuilder{BaseURL: server.URL, CircuitBreaker: cb}
+
+	for i := 0; i < 3; i++ {
+		if r := builder.Get("/user"); r.StatusCode != http.StatusInternalServerError {
+			t.Fatal("Status != Internal Server Error (500)")
+		}
+	}
+
+	r := builder.Get("/user")
+
+	var cbErr *CircuitOpenError
+	if !errors.As(r.Err, &cbErr) {
+		t.Fatalf("Expected CircuitOpenError, got %v", r.Err)
+	}
+
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Fatal("An open circuit should not send requests")
+	}
+
+	host := server.Listener.Addr().String()
+	if cb.State(host) != CircuitOpen {
+		t.Fatal("Circuit should be open")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// Probe fails, open again
+	builder.Get("/user")
+
+	if cb.State(host) != CircuitOpen {
+		t.Fatal("Circuit should be open after a failed probe")
+	}
+
+	expected := []string{"closed->open", "open->half-open", "half-open->open"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, transitions)
+	}
+
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, transitions)
+		}
+	}
+}
+
+func TestCircuitBreakerCloses(t *testing.T) {
+
+	var fail int32 = 1
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cb := &CircuitBreaker{
+		FailureRatio: 0.5,
+		MinRequests:  4,
+		CoolDown:     20 * time.Millisecond,
+	}
+
+	builder := RequestBuilder{BaseURL: server.URL, CircuitBreaker: cb}
+	host := server.Listener.Addr().String()
+
+	for i := 0; i < 4; i++ {
+		builder.Get("/user")
+	}
+
+	if cb.State(host) != CircuitOpen {
+		t.Fatal("Circuit should be open")
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	time.Sleep(30 * time.Millisecond)
+
+	if r := builder.Get("/user"); r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if cb.State(host) != CircuitClosed {
+		t.Fatal("Circuit should be closed after a successful probe")
+	}
+}
+
+func TestCircuitBreakerMockedHosts(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(
+		&Mock{URL: "http://failing.com/user", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusInternalServerError},
+		&Mock{URL: "http://healthy.com/user", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+	)
+
+	cb := &CircuitBreaker{ConsecutiveFailures: 1, CoolDown: time.Minute}
+	builder := RequestBuilder{MockServer: ms, CircuitBreaker: cb}
+
+	builder.Get("http://failing.com/user")
+
+	if cb.State("failing.com") != CircuitOpen {
+		t.Fatal("Circuit of the mocked host should be open")
+	}
+
+	if r := builder.Get("http://healthy.com/user"); r.Err != nil || r.StatusCode != http.StatusOK {
+		t.Fatalf("Other mocked hosts should not be affected, got %v", r.Err)
+	}
+}
+
+func TestCircuitBreakerIgnoresRateLimits(t *testing.T) {
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	cb := &CircuitBreaker{ConsecutiveFailures: 2, CoolDown: time.Minute}
+
+	builder := RequestBuilder{
+		BaseURL:        server.URL,
+		CircuitBreaker: cb,
+		RateLimiter:    &RateLimiter{RequestsPerSecond: 1, FailFast: true},
+	}
+
+	var rlErr *RateLimitError
+
+	for i := 0; i < 5; i++ {
+		if r := builder.Get("/user"); i > 0 && !errors.As(r.Err, &rlErr) {
+			t.Fatalf("Expected RateLimitError, got %v", r.Err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	builder.RateLimiter.FailFast = false
+
+	if r := builder.GetCtx(ctx, "/user"); !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", r.Err)
+	}
+
+	if cb.State(server.Listener.Addr().String()) != CircuitClosed {
+		t.Fatal("Rate limited requests should not open the circuit")
+	}
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatal("Rate limited requests should not reach the server")
+	}
+}
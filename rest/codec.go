@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"sync"
+)
+
+// Codec marshals and unmarshals request & response bodies of a media type.
+type Codec interface {
+
+	// MediaType the codec is registered for, like application/json
+	MediaType() string
+
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the Codec for application/json
+var JSONCodec Codec = jsonCodec{}
+
+// XMLCodec is the Codec for application/xml
+var XMLCodec Codec = xmlCodec{mediaType: "application/xml"}
+
+var codecs = map[string]Codec{
+	"application/json": JSONCodec,
+	"application/xml":  XMLCodec,
+	"text/xml":         xmlCodec{mediaType: "text/xml"},
+
+	"application/x-www-form-urlencoded": FormCodec,
+}
+var codecsMutex sync.RWMutex
+
+// RegisterCodec registers c for its media type, replacing any previous one.
+//
+// Media types with a structured suffix, like application/problem+json,
+// fall back to the codec of the suffix (application/json) when they
+// don't have one of their own.
+func RegisterCodec(c Codec) {
+	codecsMutex.Lock()
+	codecs[strings.ToLower(c.MediaType())] = c
+	codecsMutex.Unlock()
+}
+
+// CodecFor returns the codec registered for mediaType, or nil.
+// mediaType may be a full Content-Type header, parameters are ignored.
+func CodecFor(mediaType string) Codec {
+
+	mediaType = strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	if c := codecs[mediaType]; c != nil {
+		return c
+	}
+
+	// Structured syntax suffix
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		return codecs["application/"+mediaType[i+1:]]
+	}
+
+	return nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type xmlCodec struct {
+	mediaType string
+}
+
+func (c xmlCodec) MediaType() string {
+	return c.mediaType
+}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// yamlCodec handles flat YAML maps, enough to test custom codecs
+type yamlCodec struct{}
+
+func (yamlCodec) MediaType() string { return "application/yaml" }
+
+func (yamlCodec) Marshal(v interface{}) ([]byte, error) {
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("yaml: unsupported type %T", v)
+	}
+
+	lines := make([]string, 0, len(m))
+	for k, value := range m {
+		lines = append(lines, fmt.Sprintf("%s: %v\n", k, value))
+	}
+	sort.Strings(lines)
+
+	return []byte(strings.Join(lines, "")), nil
+}
+
+func (yamlCodec) Unmarshal(data []byte, v interface{}) error {
+
+	m, ok := v.(*map[string]interface{})
+	if !ok {
+		return fmt.Errorf("yaml: unsupported type %T", v)
+	}
+
+	*m = make(map[string]interface{})
+
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		k, value, _ := strings.Cut(line, ": ")
+		if n, err := strconv.Atoi(value); err == nil {
+			(*m)[k] = n
+		} else {
+			(*m)[k] = value
+		}
+	}
+
+	return nil
+}
+
+func TestCodecFor(t *testing.T) {
+
+	tests := []struct {
+		mediaType string
+		expected  Codec
+	}{
+		{"application/json", JSONCodec},
+		{"Application/JSON; charset=utf-8", JSONCodec},
+		{"application/problem+json", JSONCodec},
+		{"application/xml", XMLCodec},
+		{"application/atom+xml", XMLCodec},
+		{"text/plain", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		if c := CodecFor(test.mediaType); c != test.expected {
+			t.Fatalf("%s: expected %v, got %v", test.mediaType, test.expected, c)
+		}
+	}
+}
+
+func TestCustomCodec(t *testing.T) {
+
+	RegisterCodec(yamlCodec{})
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	mediaType := "application/vnd.users+yaml"
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users",
+		HTTPMethod:   http.MethodPost,
+		ReqHeaders:   http.Header{"Content-Type": {mediaType}, "Accept": {mediaType}},
+		ReqBody:      "id: 0\nname: Hernan\n",
+		RespHTTPCode: http.StatusCreated,
+		RespHeaders:  http.Header{"Content-Type": {mediaType}},
+		RespBody:     "id: 9\nname: Hernan\n",
+	})
+
+	builder := RequestBuilder{MockServer: ms, MediaType: mediaType}
+
+	r := builder.Post("http://mytest.com/users", map[string]interface{}{"id": 0, "name": "Hernan"})
+	if r.StatusCode != http.StatusCreated {
+		t.Fatalf("Unexpected response %d %s", r.StatusCode, r.String())
+	}
+
+	var u map[string]interface{}
+	if err := r.FillUp(&u); err != nil || u["id"] != 9 {
+		t.Fatalf("Unexpected user %v, error: %v", u, err)
+	}
+
+	builder = RequestBuilder{MockServer: ms, MediaType: "application/unknown"}
+	if r := builder.Post("http://mytest.com/users", &User{}); r.Err == nil {
+		t.Fatal("Unknown media types should get an error")
+	}
+}
+
+func TestFillUpStructuredSuffix(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  http.Header{"Content-Type": {"application/vnd.api+json; charset=utf-8"}},
+		RespBody:     `{"id":1,"name":"Max"}`,
+	})
+
+	builder := RequestBuilder{MockServer: ms}
+
+	var u User
+	if err := builder.Get("http://mytest.com/users/1").FillUp(&u); err != nil || u.Name != "Max" {
+		t.Fatalf("Unexpected user %+v, error: %v", u, err)
+	}
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"container/list"
+	"context"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -30,6 +31,7 @@ type Concurrent struct {
 	list       list.List
 	wg         sync.WaitGroup
 	reqBuilder *RequestBuilder
+	ctx        context.Context
 }
 
 // Get perform a GET HTTP verb to the specified URL concurrently.
@@ -78,7 +80,7 @@ func (c *Concurrent) doRequest(verb string, url string, body interface{}) *Futur
 
 	future := func() {
 		defer c.wg.Done()
-		response := c.reqBuilder.doRequest(verb, url, body)
+		response := c.reqBuilder.doRequest(c.ctx, verb, url, body)
 		atomic.StorePointer(&fr.p, unsafe.Pointer(response))
 	}
 
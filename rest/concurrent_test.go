@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"testing"
@@ -60,3 +62,23 @@ func TestSlowForkJoinGet(t *testing.T) {
 	}
 
 }
+
+func TestForkJoinCtxCancelled(t *testing.T) {
+
+	var f [10]*FutureResponse
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rb.ForkJoinCtx(ctx, func(cr *Concurrent) {
+		for i := range f {
+			f[i] = cr.Get("/slow/user")
+		}
+	})
+
+	for i := range f {
+		if !errors.Is(f[i].Response().Err, context.Canceled) {
+			t.Fatal("f[" + strconv.Itoa(i) + "] should be cancelled")
+		}
+	}
+}
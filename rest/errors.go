@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// Sentinel errors, to be checked with errors.Is on Response.Err
+var (
+	// ErrTimeout is a request that timed out, either by the RequestBuilder
+	// timeouts or the request context deadline.
+	ErrTimeout = errors.New("request timed out")
+
+	// ErrConnectionRefused is a request whose host refused the connection.
+	ErrConnectionRefused = errors.New("connection refused")
+
+	// ErrRedirectBlocked is a redirect not followed,
+	// as RequestBuilder.FollowRedirect is not set.
+	ErrRedirectBlocked = errors.New("Avoided redirect attempt")
+
+	// ErrMockNotFound is a mocked request not matching any mock.
+	// It is the cause of the *HTTPError of RequestBuilders with StatusErrors.
+	ErrMockNotFound = errors.New("mock not found")
+
+	// ErrNoResponse is a request whose middlewares returned a nil Response,
+	// or one with neither an *http.Response nor an error.
+	ErrNoResponse = errors.New("middleware returned no response")
+)
+
+// Header the mock server sets on responses to requests not matching any mock
+const mockNotFoundHeader = "X-Mock-Not-Found"
+
+// HTTPError is the Response error for non 2xx responses, when
+// RequestBuilder.StatusErrors is set.
+//
+//	var httpErr *rest.HTTPError
+//	if errors.As(resp.Err, &httpErr) && httpErr.IsClientError() {
+//		...
+//	}
+type HTTPError struct {
+	StatusCode int
+	Status     string
+
+	// Request HTTP Method & URL
+	Method string
+	URL    string
+
+	// Response Headers & Body. Streamed responses are read & closed
+	// to fill Body, up to the RequestBuilder MaxResponseBytes.
+	Header http.Header
+	Body   []byte
+
+	// Problem details of application/problem+json responses, or nil
+	Problem *Problem
+
+	cause error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Problem != nil {
+		return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, e.Status, e.Problem.Error())
+	}
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+}
+
+// Unwrap returns the Problem, and ErrMockNotFound for mocked requests
+// not matching any mock.
+func (e *HTTPError) Unwrap() []error {
+
+	var errs []error
+
+	if e.Problem != nil {
+		errs = append(errs, e.Problem)
+	}
+
+	if e.cause != nil {
+		errs = append(errs, e.cause)
+	}
+
+	return errs
+}
+
+// IsClientError reports if the status is 4xx
+func (e *HTTPError) IsClientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// IsServerError reports if the status is 5xx
+func (e *HTTPError) IsServerError() bool {
+	return e.StatusCode >= 500 && e.StatusCode < 600
+}
+
+func newHTTPError(resp *Response, reqURL string) *HTTPError {
+
+	e := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       resp.byteBody,
+		URL:        reqURL,
+		Problem:    resp.Problem(),
+	}
+
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+	}
+
+	if resp.Header.Get(mockNotFoundHeader) != "" {
+		e.cause = ErrMockNotFound
+	}
+
+	return e
+}
+
+// IsSuccess reports if the response status is 2xx
+func (r *Response) IsSuccess() bool {
+	return r.Response != nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// IsClientError reports if the response status is 4xx
+func (r *Response) IsClientError() bool {
+	return r.Response != nil && r.StatusCode >= 400 && r.StatusCode < 500
+}
+
+// IsServerError reports if the response status is 5xx
+func (r *Response) IsServerError() bool {
+	return r.Response != nil && r.StatusCode >= 500 && r.StatusCode < 600
+}
+
+// transportError adds the matching sentinel error to a transport error,
+// keeping its message.
+type transportError struct {
+	err      error
+	sentinel error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() []error {
+	return []error{e.err, e.sentinel}
+}
+
+// classifyError wraps transport errors matching a sentinel error
+func classifyError(err error) error {
+
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return &transportError{err: err, sentinel: ErrTimeout}
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &transportError{err: err, sentinel: ErrConnectionRefused}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,180 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusErrors(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+	}, &Mock{
+		URL:          "http://mytest.com/users/2",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+		RespBody:     "not found",
+	}, &Mock{
+		URL:          "http://mytest.com/users/3",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusBadGateway,
+	})
+
+	builder := RequestBuilder{MockServer: ms, StatusErrors: true}
+
+	if r := builder.Get("http://mytest.com/users/1"); r.Err != nil || !r.IsSuccess() {
+		t.Fatalf("Unexpected error %v", r.Err)
+	}
+
+	r := builder.Get("http://mytest.com/users/2")
+
+	var httpErr *HTTPError
+	if !errors.As(r.Err, &httpErr) || !httpErr.IsClientError() || !r.IsClientError() {
+		t.Fatalf("Expected a client HTTPError, got %v", r.Err)
+	}
+
+	if httpErr.StatusCode != http.StatusNotFound || string(httpErr.Body) != "not found" ||
+		httpErr.Method != http.MethodGet || httpErr.URL != "http://mytest.com/users/2" {
+		t.Fatalf("Unexpected error %+v", httpErr)
+	}
+
+	if r.Err.Error() != "GET http://mytest.com/users/2: 404 Not Found" {
+		t.Fatalf("Unexpected message %s", r.Err.Error())
+	}
+
+	r = builder.Get("http://mytest.com/users/3")
+	if !errors.As(r.Err, &httpErr) || !httpErr.IsServerError() || !r.IsServerError() {
+		t.Fatalf("Expected a server HTTPError, got %v", r.Err)
+	}
+
+	if r := builder.Get("http://mytest.com/users/4"); !errors.Is(r.Err, ErrMockNotFound) {
+		t.Fatalf("Expected ErrMockNotFound, got %v", r.Err)
+	}
+
+	// Not opted in
+	builder = RequestBuilder{MockServer: ms}
+	if r := builder.Get("http://mytest.com/users/2"); r.Err != nil || !r.IsClientError() {
+		t.Fatalf("Unexpected error %v", r.Err)
+	}
+}
+
+func TestStatusErrorsStream(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users/2",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+		RespBody:     "not found",
+	})
+
+	builder := RequestBuilder{MockServer: ms, StatusErrors: true, Stream: true}
+
+	r := builder.Get("http://mytest.com/users/2")
+
+	var httpErr *HTTPError
+	if !errors.As(r.Err, &httpErr) || string(httpErr.Body) != "not found" {
+		t.Fatalf("Expected an HTTPError with the streamed body, got %v", r.Err)
+	}
+
+	// Already read & closed
+	if r.streamed || r.String() != "not found" {
+		t.Fatalf("Unexpected response %q", r.String())
+	}
+
+	// Failing to read it is reported too
+	big := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer big.Close()
+
+	builder = RequestBuilder{StatusErrors: true, Stream: true, MaxResponseBytes: 10}
+	r = builder.Get(big.URL)
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(r.Err, &httpErr) || !errors.As(r.Err, &tooLarge) {
+		t.Fatalf("Expected an HTTPError and a ResponseTooLargeError, got %v", r.Err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/slow",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		Delay:        300 * time.Millisecond,
+	})
+
+	builder := RequestBuilder{Timeout: 50 * time.Millisecond, CustomPool: &CustomPool{}, MockServer: ms}
+	if r := builder.Get("http://mytest.com/slow"); !errors.Is(r.Err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", r.Err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	builder = RequestBuilder{MockServer: ms}
+	r := builder.GetCtx(ctx, "http://mytest.com/slow")
+	if !errors.Is(r.Err, ErrTimeout) || !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("Expected ErrTimeout, got %v", r.Err)
+	}
+
+	// The deadline hits while reading the body
+	slowBody := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("late"))
+	}))
+	defer slowBody.Close()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if r := new(RequestBuilder).GetCtx(ctx, slowBody.URL); !errors.Is(r.Err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", r.Err)
+	}
+
+	// The deadline hits while waiting for the rate limiter
+	builder = RequestBuilder{MockServer: ms, RateLimiter: &RateLimiter{RequestsPerSecond: 1}}
+	builder.Get("http://mytest.com/users")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if r := builder.GetCtx(ctx, "http://mytest.com/users"); !errors.Is(r.Err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", r.Err)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	if r := new(RequestBuilder).Get(closed.URL); !errors.Is(r.Err, ErrConnectionRefused) {
+		t.Fatalf("Expected ErrConnectionRefused, got %v", r.Err)
+	}
+
+	redirect := httptest.NewServer(http.RedirectHandler("/elsewhere", http.StatusFound))
+	defer redirect.Close()
+
+	if r := new(RequestBuilder).Get(redirect.URL); !errors.Is(r.Err, ErrRedirectBlocked) {
+		t.Fatalf("Expected ErrRedirectBlocked, got %v", r.Err)
+	}
+}
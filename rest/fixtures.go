@@ -0,0 +1,390 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var mockMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// MockFixture is the file representation of a Mock.
+//
+// Fixture files hold a list of mocks, either in JSON or YAML:
+//
+//	# users.yaml
+//	- url: http://api.example.com/users/1
+//	  method: GET
+//	  status: 200
+//	  response_headers:
+//	    Content-Type: application/json
+//	  response_body_file: bodies/user.json
+//
+// YAML fixtures are read without anchors, aliases, tags,
+// multiple documents nor multi-line flow collections.
+//
+// Delays are written as durations, like 250ms, and fault is one of
+// connection_reset, drop_body or malformed_chunked.
+// Body files are relative to the fixture file.
+type MockFixture struct {
+	URL              string          `json:"url"`
+	URLMatch         string          `json:"url_match,omitempty"`
+	QueryMatch       string          `json:"query_match,omitempty"`
+	Method           string          `json:"method"`
+	RequestHeaders   FixtureHeader   `json:"request_headers,omitempty"`
+	RequestBody      string          `json:"request_body,omitempty"`
+	RequestBodyFile  string          `json:"request_body_file,omitempty"`
+	RequestBodyMatch string          `json:"request_body_match,omitempty"`
+	Status           int             `json:"status,omitempty"`
+	ResponseHeaders  FixtureHeader   `json:"response_headers,omitempty"`
+	ResponseBody     string          `json:"response_body,omitempty"`
+	ResponseBodyFile string          `json:"response_body_file,omitempty"`
+	Template         bool            `json:"template,omitempty"`
+	Responses        []FixtureResult `json:"responses,omitempty"`
+	Times            int             `json:"times,omitempty"`
+	Scenario         string          `json:"scenario,omitempty"`
+	RequiredState    string          `json:"required_state,omitempty"`
+	NewState         string          `json:"new_state,omitempty"`
+	Delay            string          `json:"delay,omitempty"`
+	BodyDelay        string          `json:"body_delay,omitempty"`
+	Fault            string          `json:"fault,omitempty"`
+}
+
+// FixtureResult is the file representation of a MockResponse
+type FixtureResult struct {
+	Status   int           `json:"status,omitempty"`
+	Headers  FixtureHeader `json:"headers,omitempty"`
+	Body     string        `json:"body,omitempty"`
+	BodyFile string        `json:"body_file,omitempty"`
+}
+
+// FixtureHeader is an http.Header whose values may be written
+// either as a single value or as a list of values.
+// Numbers and booleans are taken as their text, like "Retry-After": 0.
+type FixtureHeader http.Header
+
+// UnmarshalJSON implements json.Unmarshaler
+func (h *FixtureHeader) UnmarshalJSON(b []byte) error {
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	header := make(http.Header)
+
+	for k, v := range raw {
+		// Numbers are kept as written
+		d := json.NewDecoder(bytes.NewReader(v))
+		d.UseNumber()
+
+		var value interface{}
+		if err := d.Decode(&value); err != nil {
+			return err
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+
+		for _, value := range values {
+			switch value.(type) {
+			case string, json.Number, bool:
+				header.Add(k, fmt.Sprint(value))
+			default:
+				return fmt.Errorf("header %s must be a value or a list of values", k)
+			}
+		}
+	}
+
+	*h = FixtureHeader(header)
+	return nil
+}
+
+// LoadMockups reads every .json, .yaml & .yml fixture file in dir,
+// and registers their mocks.
+//
+// Sub directories are not read, so they are a good place for body files.
+// If any fixture is invalid, no mock is registered and the returned error
+// describes every problem found.
+func LoadMockups(dir string) error {
+
+	var paths []string
+
+	for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, matches...)
+	}
+
+	sort.Strings(paths)
+	return LoadMockupsFiles(paths...)
+}
+
+// LoadMockupsFiles reads the given fixture files and registers their mocks.
+//
+// If any fixture is invalid, no mock is registered and the returned error
+// describes every problem found.
+func LoadMockupsFiles(paths ...string) error {
+
+	var mocks []*Mock
+	var errs []error
+
+	for _, path := range paths {
+		m, err := ReadMockupsFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		mocks = append(mocks, m...)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return AddMockups(mocks...)
+}
+
+// ReadMockupsFile reads and validates the mocks of a JSON or YAML fixture file,
+// without registering them.
+func ReadMockupsFile(path string) ([]*Mock, error) {
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is converted to JSON, so both share the same field names & decoding
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if b, err = yamlToJSON(b); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+	}
+
+	var fixtures []MockFixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	dir := filepath.Dir(path)
+	mocks := make([]*Mock, 0, len(fixtures))
+
+	var errs []error
+
+	for i, f := range fixtures {
+		m, err := f.mock(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: mock #%d (%s %s): %s", path, i+1, f.Method, f.URL, err.Error()))
+			continue
+		}
+		mocks = append(mocks, m)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return mocks, nil
+}
+
+// mock validates the fixture and builds its Mock. Body files are relative to dir.
+func (f *MockFixture) mock(dir string) (*Mock, error) {
+
+	urlMatch, err := fixtureURLMatch(f.URLMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(f.URL)
+	switch {
+	case f.URL == "":
+		return nil, errors.New("url is required")
+	case urlMatch == URLRegexp:
+		// Not an URL, checked by newURLMatcher
+	case err != nil:
+		return nil, fmt.Errorf("bad url: %s", err.Error())
+	case u.Scheme == "" || u.Host == "":
+		return nil, fmt.Errorf("bad url %q: must be absolute", f.URL)
+	}
+
+	method := strings.ToUpper(f.Method)
+	if !matchVerbs(method, mockMethods) {
+		return nil, fmt.Errorf("unknown method %q", f.Method)
+	}
+
+	m := &Mock{
+		URL:           f.URL,
+		URLMatch:      urlMatch,
+		HTTPMethod:    method,
+		ReqHeaders:    http.Header(f.RequestHeaders),
+		ReqBody:       f.RequestBody,
+		RespHeaders:   http.Header(f.ResponseHeaders),
+		RespBody:      f.ResponseBody,
+		Template:      f.Template,
+		Times:         f.Times,
+		Scenario:      f.Scenario,
+		RequiredState: f.RequiredState,
+		NewState:      f.NewState,
+	}
+
+	switch strings.ToLower(f.RequestBodyMatch) {
+	case "", "exact":
+		m.ReqBodyMatch = BodyExact
+	case "json":
+		m.ReqBodyMatch = BodyJSON
+	default:
+		return nil, fmt.Errorf("unknown request_body_match %q, use exact or json", f.RequestBodyMatch)
+	}
+
+	switch strings.ToLower(f.QueryMatch) {
+	case "", "exact":
+		m.QueryMatch = QueryExact
+	case "ignore":
+		m.QueryMatch = QueryIgnore
+	case "subset":
+		m.QueryMatch = QuerySubset
+	default:
+		return nil, fmt.Errorf("unknown query_match %q, use exact, ignore or subset", f.QueryMatch)
+	}
+
+	if urlMatch != URLExact || m.QueryMatch != QueryExact {
+		if _, err := newURLMatcher(m); err != nil {
+			return nil, fmt.Errorf("bad url %q: %s", f.URL, err.Error())
+		}
+	}
+
+	if m.RespHTTPCode, err = fixtureStatus(f.Status); err != nil {
+		return nil, err
+	}
+
+	if m.Delay, err = fixtureDuration("delay", f.Delay); err != nil {
+		return nil, err
+	}
+
+	if m.BodyDelay, err = fixtureDuration("body_delay", f.BodyDelay); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(f.Fault) {
+	case "", "none":
+		m.Fault = FaultNone
+	case "connection_reset":
+		m.Fault = FaultConnectionReset
+	case "drop_body":
+		m.Fault = FaultDropBody
+	case "malformed_chunked":
+		m.Fault = FaultMalformedChunked
+	default:
+		return nil, fmt.Errorf("unknown fault %q, use connection_reset, drop_body or malformed_chunked", f.Fault)
+	}
+
+	if f.RequestBodyFile != "" {
+		if m.ReqBody, err = readBodyFile(dir, f.RequestBodyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.ResponseBodyFile != "" {
+		if m.RespBody, err = readBodyFile(dir, f.ResponseBodyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, r := range f.Responses {
+
+		resp := MockResponse{
+			Headers: http.Header(r.Headers),
+			Body:    r.Body,
+		}
+
+		if resp.HTTPCode, err = fixtureStatus(r.Status); err != nil {
+			return nil, fmt.Errorf("response #%d: %s", i+1, err.Error())
+		}
+
+		if r.BodyFile != "" {
+			if resp.Body, err = readBodyFile(dir, r.BodyFile); err != nil {
+				return nil, fmt.Errorf("response #%d: %s", i+1, err.Error())
+			}
+		}
+
+		m.Responses = append(m.Responses, resp)
+	}
+
+	if m.Template {
+		if err := m.parseTemplates(); err != nil {
+			return nil, fmt.Errorf("bad template: %s", err.Error())
+		}
+	}
+
+	return m, nil
+}
+
+func fixtureURLMatch(s string) (URLMatch, error) {
+	switch strings.ToLower(s) {
+	case "", "exact":
+		return URLExact, nil
+	case "pattern":
+		return URLPattern, nil
+	case "regexp":
+		return URLRegexp, nil
+	default:
+		return URLExact, fmt.Errorf("unknown url_match %q, use exact, pattern or regexp", s)
+	}
+}
+
+// fixtureStatus validates a status code, defaulting to 200(OK)
+func fixtureStatus(status int) (int, error) {
+	switch {
+	case status == 0:
+		return http.StatusOK, nil
+	case status < 100 || status > 599:
+		return 0, fmt.Errorf("bad status %d", status)
+	default:
+		return status, nil
+	}
+}
+
+// fixtureDuration parses a duration like "250ms", empty means zero
+func fixtureDuration(field string, s string) (time.Duration, error) {
+
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("bad %s %q, use a duration like 250ms", field, s)
+	}
+
+	return d, nil
+}
+
+func readBodyFile(dir string, name string) (string, error) {
+
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("body file: %s", err.Error())
+	}
+
+	return string(b), nil
+}
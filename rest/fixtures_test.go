@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadMockups(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	if err := LoadMockups("testdata/mockups"); err != nil {
+		t.Fatal(err)
+	}
+
+	var u User
+
+	r := Get("http://fixtures.com/users/1")
+	if err := r.FillUp(&u); err != nil || u.Name != "Max" {
+		t.Fatalf("Unexpected user %+v, error: %v", u, err)
+	}
+
+	if r := Post("http://fixtures.com/users", &User{Name: "Matilda"}); r.StatusCode != http.StatusCreated {
+		t.Fatal("Status != Created (201)")
+	}
+
+	r = Get("http://fixtures.com/users/3/avatar?size=64")
+	if avatar := r.Header.Get("X-Avatar-Url"); avatar != "http://cdn.fixtures.com/avatars/3.png" {
+		t.Fatalf("Unexpected avatar url %s", avatar)
+	}
+
+	for _, expected := range []int{503, 200} {
+		if r := Get("http://fixtures.com/health"); r.StatusCode != expected {
+			t.Fatalf("Expected %d, got %d", expected, r.StatusCode)
+		}
+	}
+}
+
+func TestLoadMockupsInvalid(t *testing.T) {
+
+	defer FlushMockups()
+
+	err := LoadMockups("testdata/invalid")
+	if err == nil {
+		t.Fatal("Invalid fixtures should get an error")
+	}
+
+	for _, expected := range []string{
+		"mock #1 (GET /users/1): bad url",
+		"mock #2 (GTE http://fixtures.com/users/1): unknown method",
+		"mock #3 (GET http://fixtures.com/users/2): body file",
+		"mock #4 (GET http://fixtures.com/users/(): bad url",
+		"mock #5 (GET http://fixtures.com/users/3): unknown fault",
+		"mock #6 (GET http://fixtures.com/users/4): bad template",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected %q in %q", expected, err.Error())
+		}
+	}
+}
+
+func TestFixtureHeaderScalars(t *testing.T) {
+
+	var h FixtureHeader
+
+	err := json.Unmarshal([]byte(`{"Retry-After": 0, "X-Cached": true, "X-Big": 12345678901234567890, "Vary": ["Accept", 1]}`), &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FixtureHeader{
+		"Retry-After": {"0"},
+		"X-Cached":    {"true"},
+		"X-Big":       {"12345678901234567890"},
+		"Vary":        {"Accept", "1"},
+	}
+
+	if !reflect.DeepEqual(h, expected) {
+		t.Fatalf("Expected %v, got %v", expected, h)
+	}
+
+	if err := json.Unmarshal([]byte(`{"X-Object": {"a": 1}}`), &h); err == nil {
+		t.Fatal("Object header values should get an error")
+	}
+}
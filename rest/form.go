@@ -0,0 +1,267 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FormCodec is the Codec for application/x-www-form-urlencoded.
+//
+// It marshals url.Values, map[string]string, map[string][]string and structs,
+// and unmarshals into pointers to any of them. Struct fields are named by
+// their `form` tag, or their field name, and may be strings, bools, numbers
+// or slices of them. Use `form:"-"` to skip a field and
+// `form:"name,omitempty"` to skip it when empty.
+//
+//	type Token struct {
+//		GrantType string   `form:"grant_type"`
+//		Scope     []string `form:"scope,omitempty"`
+//	}
+var FormCodec Codec = formCodec{}
+
+type formCodec struct{}
+
+func (formCodec) MediaType() string {
+	return "application/x-www-form-urlencoded"
+}
+
+func (formCodec) Marshal(v interface{}) ([]byte, error) {
+
+	values, err := formValues(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(values.Encode()), nil
+}
+
+func (formCodec) Unmarshal(data []byte, v interface{}) error {
+
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch fill := v.(type) {
+	case *url.Values:
+		*fill = values
+		return nil
+
+	case *map[string][]string:
+		*fill = values
+		return nil
+
+	case *map[string]string:
+		*fill = make(map[string]string, len(values))
+		for k := range values {
+			(*fill)[k] = values.Get(k)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: can't unmarshal into %T", v)
+	}
+
+	rv = rv.Elem()
+
+	for i := 0; i < rv.NumField(); i++ {
+
+		name, _, ok := formField(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		fieldValues, found := values[name]
+		if !found {
+			continue
+		}
+
+		if err := setFormField(rv.Field(i), fieldValues); err != nil {
+			return fmt.Errorf("form: field %s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func formValues(v interface{}) (url.Values, error) {
+
+	switch body := v.(type) {
+	case url.Values:
+		return body, nil
+
+	case map[string][]string:
+		return url.Values(body), nil
+
+	case map[string]string:
+		values := make(url.Values, len(body))
+		for k, value := range body {
+			values.Set(k, value)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form: can't marshal %T", v)
+	}
+
+	values := make(url.Values)
+
+	for i := 0; i < rv.NumField(); i++ {
+
+		name, omitEmpty, ok := formField(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		field := rv.Field(i)
+		if omitEmpty && field.IsZero() {
+			continue
+		}
+
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+			for j := 0; j < field.Len(); j++ {
+				s, err := formatFormValue(field.Index(j))
+				if err != nil {
+					return nil, fmt.Errorf("form: field %s: %s", name, err.Error())
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+
+		s, err := formatFormValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("form: field %s: %s", name, err.Error())
+		}
+		values.Set(name, s)
+	}
+
+	return values, nil
+}
+
+// formField returns the form name of an exported struct field
+func formField(f reflect.StructField) (name string, omitEmpty bool, ok bool) {
+
+	if f.PkgPath != "" {
+		return "", false, false
+	}
+
+	tag := f.Tag.Get("form")
+	if tag == "-" {
+		return "", false, false
+	}
+
+	name = f.Name
+	if parts := strings.Split(tag, ","); parts[0] != "" {
+		name = parts[0]
+		omitEmpty = containsString(parts[1:], "omitempty")
+	} else {
+		omitEmpty = containsString(parts, "omitempty")
+	}
+
+	return name, omitEmpty, true
+}
+
+func formatFormValue(v reflect.Value) (string, error) {
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func setFormField(field reflect.Value, values []string) error {
+
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := parseFormValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return parseFormValue(field, values[0])
+}
+
+func parseFormValue(v reflect.Value, s string) error {
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type tokenRequest struct {
+	GrantType string   `form:"grant_type"`
+	ClientID  string   `form:"client_id"`
+	Scope     []string `form:"scope,omitempty"`
+	Refresh   *bool    `form:"refresh,omitempty"`
+	Secret    string   `form:"-"`
+	Retries   int
+}
+
+func TestFormMarshal(t *testing.T) {
+
+	tests := []struct {
+		body     interface{}
+		expected string
+	}{
+		{url.Values{"b": {"2"}, "a": {"1", "3"}}, "a=1&a=3&b=2"},
+		{map[string]string{"q": "go rest"}, "q=go+rest"},
+		{&tokenRequest{GrantType: "client_credentials", ClientID: "abc", Secret: "s", Retries: 2}, "Retries=2&client_id=abc&grant_type=client_credentials"},
+		{tokenRequest{GrantType: "password", Scope: []string{"read", "write"}}, "Retries=0&client_id=&grant_type=password&scope=read&scope=write"},
+	}
+
+	for _, test := range tests {
+		b, err := FormCodec.Marshal(test.body)
+		if err != nil || string(b) != test.expected {
+			t.Fatalf("Expected %s, got %s, error: %v", test.expected, b, err)
+		}
+	}
+
+	if _, err := FormCodec.Marshal(42); err == nil {
+		t.Fatal("Should not marshal an int")
+	}
+}
+
+func TestFormPost(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/oauth/token",
+		HTTPMethod:   http.MethodPost,
+		ReqHeaders:   http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+		ReqBody:      "Retries=0&client_id=abc&grant_type=client_credentials&scope=read",
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  http.Header{"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"}},
+		RespBody:     "grant_type=refresh_token&client_id=abc&scope=read&scope=write&refresh=true&Retries=3",
+	})
+
+	builder := RequestBuilder{MockServer: ms, ContentType: FORM}
+
+	r := builder.Post("http://mytest.com/oauth/token", &tokenRequest{
+		GrantType: "client_credentials",
+		ClientID:  "abc",
+		Scope:     []string{"read"},
+	})
+	if r.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected response %d %s", r.StatusCode, r.String())
+	}
+
+	if accept := ms.Calls()[0].Header.Get("Accept"); accept != "" {
+		t.Fatalf("Forms should not set Accept, got %s", accept)
+	}
+
+	var token tokenRequest
+	if err := r.FillUp(&token); err != nil {
+		t.Fatal(err)
+	}
+
+	refresh := true
+	expected := tokenRequest{
+		GrantType: "refresh_token",
+		ClientID:  "abc",
+		Scope:     []string{"read", "write"},
+		Refresh:   &refresh,
+		Retries:   3,
+	}
+	if !reflect.DeepEqual(token, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, token)
+	}
+
+	var values url.Values
+	if err := r.FillUp(&values); err != nil || values.Get("grant_type") != "refresh_token" {
+		t.Fatalf("Unexpected values %v, error: %v", values, err)
+	}
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ResponseTooLargeError is the Response error when the response body
+// is bigger than the RequestBuilder MaxResponseBytes.
+type ResponseTooLargeError struct {
+	Limit int64
+
+	// Content-Length announced by the server, -1 if unknown
+	ContentLength int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	if e.ContentLength >= 0 {
+		return fmt.Sprintf("response body of %d bytes exceeds the limit of %d bytes", e.ContentLength, e.Limit)
+	}
+	return fmt.Sprintf("response body exceeds the limit of %d bytes", e.Limit)
+}
+
+// readBody reads the whole body, failing once it reads more than limit bytes.
+// Zero means no limit.
+func readBody(body io.Reader, limit int64, contentLength int64) ([]byte, error) {
+
+	if limit <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > limit {
+		return nil, &ResponseTooLargeError{Limit: limit, ContentLength: contentLength}
+	}
+
+	return b, nil
+}
+
+// limitedBody is a streamed body, which fails once more than limit
+// bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func newLimitedBody(body io.ReadCloser, limit int64, contentLength int64) *limitedBody {
+	return &limitedBody{
+		ReadCloser: body,
+		remaining:  limit,
+		err:        &ResponseTooLargeError{Limit: limit, ContentLength: contentLength},
+	}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+
+	if l.remaining < 0 {
+		return 0, l.err
+	}
+
+	// Read one more byte than allowed, to find out if the limit is exceeded
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+
+	if l.remaining < 0 {
+		return n - 1, l.err
+	}
+
+	return n, err
+}
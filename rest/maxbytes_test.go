@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newBigServer(t *testing.T) *httptest.Server {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+
+		body := strings.Repeat("a", 1000)
+
+		// Unknown length
+		if req.URL.Path == "/chunked" {
+			for i := 0; i < 10; i++ {
+				w.Write([]byte(body[:100]))
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+
+	server := newBigServer(t)
+
+	tests := []struct {
+		path          string
+		limit         int64
+		contentLength int64
+	}{
+		{"/", 100, 1000},
+		{"/chunked", 100, -1},
+		{"/", 999, 1000},
+		{"/chunked", 999, -1},
+	}
+
+	for _, test := range tests {
+		builder := RequestBuilder{MaxResponseBytes: test.limit, DisableCache: true}
+
+		var tooLarge *ResponseTooLargeError
+		if r := builder.Get(server.URL + test.path); !errors.As(r.Err, &tooLarge) {
+			t.Fatalf("%s %d: expected a ResponseTooLargeError, got %v", test.path, test.limit, r.Err)
+		}
+
+		if tooLarge.Limit != test.limit || tooLarge.ContentLength != test.contentLength {
+			t.Fatalf("Unexpected error %+v", tooLarge)
+		}
+	}
+
+	builder := RequestBuilder{MaxResponseBytes: 1000, DisableCache: true}
+	if r := builder.Get(server.URL + "/chunked"); r.Err != nil || len(r.Bytes()) != 1000 {
+		t.Fatalf("Unexpected response of %d bytes, error: %v", len(r.Bytes()), r.Err)
+	}
+}
+
+func TestMaxResponseBytesHead(t *testing.T) {
+
+	server := newBigServer(t)
+
+	builder := RequestBuilder{MaxResponseBytes: 100, DisableCache: true}
+
+	r := builder.Head(server.URL)
+	if r.Err != nil || r.StatusCode != http.StatusOK || r.ContentLength != 1000 {
+		t.Fatalf("Unexpected response %d of %d bytes, error: %v", r.StatusCode, r.ContentLength, r.Err)
+	}
+}
+
+func TestMaxResponseBytesCircuitBreaker(t *testing.T) {
+
+	server := newBigServer(t)
+
+	cb := &CircuitBreaker{ConsecutiveFailures: 1}
+	builder := RequestBuilder{MaxResponseBytes: 100, DisableCache: true, CircuitBreaker: cb}
+
+	for i := 0; i < 3; i++ {
+		var tooLarge *ResponseTooLargeError
+		if r := builder.Get(server.URL); !errors.As(r.Err, &tooLarge) {
+			t.Fatalf("Expected a ResponseTooLargeError, got %v", r.Err)
+		}
+	}
+
+	if cb.State(server.Listener.Addr().String()) != CircuitClosed {
+		t.Fatal("Too large responses should not open the circuit")
+	}
+}
+
+func TestDefaultMaxResponseBytes(t *testing.T) {
+
+	server := newBigServer(t)
+
+	DefaultMaxResponseBytes = 100
+	defer func() { DefaultMaxResponseBytes = 0 }()
+
+	builder := RequestBuilder{DisableCache: true}
+	if r := builder.Get(server.URL); r.Err == nil {
+		t.Fatal("Should exceed the default limit")
+	}
+
+	builder = RequestBuilder{DisableCache: true, MaxResponseBytes: -1}
+	if r := builder.Get(server.URL); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+}
+
+func TestMaxResponseBytesStream(t *testing.T) {
+
+	server := newBigServer(t)
+
+	builder := RequestBuilder{MaxResponseBytes: 500, Stream: true}
+
+	r := builder.Get(server.URL + "/chunked")
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r.Stream())
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || len(b) != 500 {
+		t.Fatalf("Read %d bytes, error: %v", len(b), err)
+	}
+}
+
+func TestMaxCacheSizeBigResponse(t *testing.T) {
+
+	server := newBigServer(t)
+
+	maxCacheSize := MaxCacheSize
+	MaxCacheSize = KB / 2
+	defer func() { MaxCacheSize = maxCacheSize }()
+
+	builder := RequestBuilder{}
+
+	for i := 0; i < 2; i++ {
+		if r := builder.Get(server.URL + "/uncacheable"); r.Err != nil || r.CacheHit() {
+			t.Fatalf("Response bigger than the cache should not be cached, error: %v", r.Err)
+		}
+	}
+}
@@ -1 +1,130 @@
-package rest
\ No newline at end of file
+package rest
+
+import (
+	"net/http"
+	"sync"
+)
+
+// RoundTripFunc sends a fully built request and returns its Response.
+//
+// The returned Response must never be nil. Transport failures are
+// reported through Response.Err.
+type RoundTripFunc func(req *http.Request) *Response
+
+// Middleware wraps the round trip performed by a RequestBuilder.
+// It sees the *http.Request before it is sent and the *Response after
+// it has been read, and may modify both, or even short-circuit the
+// request by not calling next at all.
+//
+//	logger := func(next rest.RoundTripFunc) rest.RoundTripFunc {
+//		return func(req *http.Request) *rest.Response {
+//			resp := next(req)
+//			log.Println(req.Method, req.URL, resp.Err)
+//			return resp
+//		}
+//	}
+//
+// Responses served from the cache do not go through the middlewares.
+// Streamed responses reach them unread.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+var globalMiddlewares []Middleware
+var globalMiddlewaresMutex sync.RWMutex
+
+// AddMiddlewares registers middlewares used by *every* RequestBuilder.
+//
+// Global middlewares run before the RequestBuilder ones. Within each group,
+// middlewares run in the order they were added, so the first one added
+// is the first one to see the request and the last one to see the response.
+func AddMiddlewares(mws ...Middleware) {
+	globalMiddlewaresMutex.Lock()
+	globalMiddlewares = append(globalMiddlewares, mws...)
+	globalMiddlewaresMutex.Unlock()
+}
+
+// FlushMiddlewares removes every global middleware.
+func FlushMiddlewares() {
+	globalMiddlewaresMutex.Lock()
+	globalMiddlewares = nil
+	globalMiddlewaresMutex.Unlock()
+}
+
+// roundTrip builds the chain for a request: global middlewares, then
+// RequestBuilder middlewares, the rate limiter, the circuit breaker
+// and finally the actual client call. The limiter wraps the breaker,
+// so its rejections and waits never count as host failures.
+func (rb *RequestBuilder) roundTrip(client *http.Client) RoundTripFunc {
+
+	rt := rb.doRoundTrip(client)
+
+	if rb.CircuitBreaker != nil {
+		rt = rb.CircuitBreaker.middleware(rt)
+	}
+
+	if rb.RateLimiter != nil {
+		rt = rb.RateLimiter.middleware(rt)
+	}
+
+	for i := len(rb.Middlewares) - 1; i >= 0; i-- {
+		rt = rb.Middlewares[i](rt)
+	}
+
+	globalMiddlewaresMutex.RLock()
+	for i := len(globalMiddlewares) - 1; i >= 0; i-- {
+		rt = globalMiddlewares[i](rt)
+	}
+	globalMiddlewaresMutex.RUnlock()
+
+	return rt
+}
+
+func (rb *RequestBuilder) doRoundTrip(client *http.Client) RoundTripFunc {
+
+	limit := rb.getMaxResponseBytes()
+	stream := rb.Stream
+
+	return func(req *http.Request) *Response {
+
+		resp := new(Response)
+
+		httpResp, err := client.Do(req)
+		if err != nil {
+			resp.Err = classifyError(err)
+			return resp
+		}
+
+		// Don't even start reading a body announced as too large.
+		// HEAD responses announce the length of a body they don't have.
+		hasBody := req.Method != http.MethodHead && httpResp.Body != http.NoBody
+		if limit > 0 && hasBody && httpResp.ContentLength > limit {
+			httpResp.Body.Close()
+			resp.Response = httpResp
+			resp.Err = &ResponseTooLargeError{Limit: limit, ContentLength: httpResp.ContentLength}
+			return resp
+		}
+
+		// Leave the body to be read by the caller
+		if stream {
+			if limit > 0 {
+				httpResp.Body = newLimitedBody(httpResp.Body, limit, httpResp.ContentLength)
+			}
+			resp.Response = httpResp
+			resp.streamed = true
+			return resp
+		}
+
+		// Read response
+		defer httpResp.Body.Close()
+		respBody, err := readBody(httpResp.Body, limit, httpResp.ContentLength)
+		if err != nil {
+			resp.Response = httpResp
+			resp.Err = classifyError(err)
+			return resp
+		}
+
+		resp.Response = httpResp
+		resp.byteBody = respBody
+
+		return resp
+	}
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+
+	defer FlushMiddlewares()
+
+	var calls []string
+
+	record := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) *Response {
+				calls = append(calls, name+" req")
+				resp := next(req)
+				calls = append(calls, name+" resp")
+				return resp
+			}
+		}
+	}
+
+	AddMiddlewares(record("global1"), record("global2"))
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		Middlewares: []Middleware{record("builder")},
+	}
+
+	r := builder.Get("/user")
+	if r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	expected := []string{
+		"global1 req", "global2 req", "builder req",
+		"builder resp", "global2 resp", "global1 resp",
+	}
+
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %v, got %v", expected, calls)
+	}
+}
+
+func TestMiddlewareModifiesRequest(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL: server.URL,
+		Middlewares: []Middleware{
+			func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) *Response {
+					req.Header.Set("X-Test", "test")
+					return next(req)
+				}
+			},
+		},
+	}
+
+	r := builder.Get("/header")
+	if r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+
+	errBlocked := errors.New("blocked")
+
+	builder := RequestBuilder{
+		BaseURL: server.URL,
+		Middlewares: []Middleware{
+			func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) *Response {
+					return &Response{Err: errBlocked}
+				}
+			},
+		},
+	}
+
+	r := builder.Get("/user")
+	if r.Err != errBlocked {
+		t.Fatalf("Expected %v, got %v", errBlocked, r.Err)
+	}
+}
+
+func TestMiddlewareNoResponse(t *testing.T) {
+
+	for _, resp := range []*Response{nil, {}} {
+
+		resp := resp
+
+		builder := RequestBuilder{
+			BaseURL: server.URL,
+			Middlewares: []Middleware{
+				func(next RoundTripFunc) RoundTripFunc {
+					return func(req *http.Request) *Response {
+						return resp
+					}
+				},
+			},
+		}
+
+		r := builder.Get("/user")
+		if !errors.Is(r.Err, ErrNoResponse) {
+			t.Fatalf("Expected %v, got %v", ErrNoResponse, r.Err)
+		}
+	}
+}
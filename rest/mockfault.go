@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Fault is a network failure injected by a Mock
+type Fault int
+
+const (
+	// FaultNone sends the response as is
+	FaultNone Fault = iota
+	// FaultConnectionReset resets the TCP connection without responding
+	FaultConnectionReset
+	// FaultDropBody closes the connection after sending half of the response body
+	FaultDropBody
+	// FaultMalformedChunked sends the response body with an invalid chunked encoding
+	FaultMalformedChunked
+)
+
+// respond writes the mock response, injecting its latency & faults
+func respond(writer http.ResponseWriter, req *http.Request, m *Mock, resp MockResponse, call MockCall) {
+
+	if m.Delay > 0 && !wait(req.Context(), m.Delay) {
+		return
+	}
+
+	if m.Template {
+		var err error
+		if resp, err = renderMockResponse(resp, call); err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	// Add headers
+	for k, v := range resp.Headers {
+		for _, vv := range v {
+			writer.Header().Add(k, expandVars(vv, call.Vars))
+		}
+	}
+
+	switch m.Fault {
+	case FaultConnectionReset:
+		resetConnection(writer)
+		return
+
+	case FaultDropBody, FaultMalformedChunked:
+		writeBrokenResponse(writer, req, m, resp)
+		return
+	}
+
+	writer.WriteHeader(resp.HTTPCode)
+
+	if m.BodyDelay > 0 {
+		if f, ok := writer.(http.Flusher); ok {
+			f.Flush()
+		}
+		if !wait(req.Context(), m.BodyDelay) {
+			return
+		}
+	}
+
+	writer.Write([]byte(resp.Body))
+}
+
+// resetConnection closes the connection with a TCP RST instead of a FIN
+func resetConnection(writer http.ResponseWriter) {
+
+	conn, _, err := hijack(writer)
+	if err != nil {
+		return
+	}
+
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+
+	conn.Close()
+}
+
+// writeBrokenResponse writes the raw response, either with a truncated body,
+// or with a malformed chunked body, and closes the connection.
+func writeBrokenResponse(writer http.ResponseWriter, req *http.Request, m *Mock, resp MockResponse) {
+
+	header := writer.Header().Clone()
+
+	conn, buf, err := hijack(writer)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	body := resp.Body
+
+	if m.Fault == FaultDropBody {
+		// Promise at least one byte, so the body is always incomplete
+		length := len(body)
+		if length == 0 {
+			length = 1
+		}
+		header.Set("Content-Length", strconv.Itoa(length))
+		body = body[:len(body)/2]
+	} else {
+		header.Set("Transfer-Encoding", "chunked")
+		body = "zz\r\n" + body + "\r\n"
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", resp.HTTPCode, http.StatusText(resp.HTTPCode))
+	header.Write(buf)
+	buf.WriteString("\r\n")
+
+	if m.BodyDelay > 0 {
+		if buf.Flush() != nil || !wait(req.Context(), m.BodyDelay) {
+			return
+		}
+	}
+
+	buf.WriteString(body)
+	buf.Flush()
+}
+
+func hijack(writer http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+
+	hj, ok := writer.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("mock server doesn't support hijacking")
+	}
+
+	return hj.Hijack()
+}
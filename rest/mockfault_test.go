@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMockupDelay(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	myURL := "http://mytest.com/slow"
+
+	ms.AddMockups(&Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "slow",
+		Delay:        300 * time.Millisecond,
+	})
+
+	builder := RequestBuilder{Timeout: 100 * time.Millisecond, CustomPool: &CustomPool{}, MockServer: ms}
+	if r := builder.Get(myURL); r.Err == nil {
+		t.Fatal("Delayed response should time out")
+	}
+
+	builder = RequestBuilder{Timeout: time.Second, CustomPool: &CustomPool{}, MockServer: ms}
+	if r := builder.Get(myURL); r.Err != nil || r.String() != "slow" {
+		t.Fatalf("Unexpected response %q, error: %v", r.String(), r.Err)
+	}
+}
+
+func TestMockupBodyDelay(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	myURL := "http://mytest.com/slow-body"
+
+	ms.AddMockups(&Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "slow",
+		BodyDelay:    300 * time.Millisecond,
+	})
+
+	// Headers arrive in time, so the request timeout doesn't apply to the body
+	builder := RequestBuilder{Timeout: 100 * time.Millisecond, CustomPool: &CustomPool{}, MockServer: ms}
+
+	start := time.Now()
+	if r := builder.Get(myURL); r.Err != nil || r.String() != "slow" {
+		t.Fatalf("Unexpected response %q, error: %v", r.String(), r.Err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("Body should be delayed, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if r := builder.GetCtx(ctx, myURL); r.Err == nil {
+		t.Fatal("Delayed body should exceed the context deadline")
+	}
+}
+
+func TestMockupFaults(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	faults := map[string]Fault{
+		"http://mytest.com/reset":     FaultConnectionReset,
+		"http://mytest.com/drop-body": FaultDropBody,
+		"http://mytest.com/chunked":   FaultMalformedChunked,
+	}
+
+	for myURL, fault := range faults {
+		ms.AddMockups(&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     `{"name":"Hernan"}`,
+			Fault:        fault,
+		})
+	}
+
+	builder := RequestBuilder{MockServer: ms}
+
+	for myURL := range faults {
+		if r := builder.Get(myURL); r.Err == nil {
+			t.Fatalf("%s: should get an error", myURL)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// MockServer is an isolated mockup server, with its own mocks, scenarios
+// and recorded calls, so tests using it can run in parallel.
+//
+// Attach it to a RequestBuilder, or to a context with WithMockServer,
+// and every request made through them will be sent to it.
+//
+//	ms := rest.NewMockServer()
+//	t.Cleanup(ms.Close)
+//
+//	ms.AddMockups(&rest.Mock{...})
+//	rb := rest.RequestBuilder{MockServer: ms}
+type MockServer struct {
+	server *httptest.Server
+	url    *url.URL
+
+	mutex     sync.RWMutex
+	mocks     map[string][]*Mock
+	matchers  []*urlMatcher
+	uses      map[*Mock]int
+	scenarios map[string]string
+	calls     []MockCall
+	cassette  *Cassette
+}
+
+type mockServerKey struct{}
+
+// NewMockServer starts a new MockServer.
+// It should be closed when done, usually with t.Cleanup(ms.Close).
+func NewMockServer() *MockServer {
+	ms := newMockServer()
+	ms.start()
+	return ms
+}
+
+// WithMockServer returns a copy of ctx, which sends every request
+// made with it to the mock server ms.
+func WithMockServer(ctx context.Context, ms *MockServer) context.Context {
+	return context.WithValue(ctx, mockServerKey{}, ms)
+}
+
+func newMockServer() *MockServer {
+	return &MockServer{
+		mocks:     make(map[string][]*Mock),
+		uses:      make(map[*Mock]int),
+		scenarios: make(map[string]string),
+	}
+}
+
+func (ms *MockServer) start() {
+
+	if ms.server != nil {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", ms.handler)
+	ms.server = httptest.NewServer(mux)
+
+	var err error
+	if ms.url, err = url.Parse(ms.server.URL); err != nil {
+		panic(err)
+	}
+}
+
+// Close stops the mock server.
+func (ms *MockServer) Close() {
+
+	if ms.server == nil {
+		return
+	}
+
+	ms.server.Close()
+	ms.server = nil
+	ms.url = nil
+}
+
+// URL returns the mock server base url.
+func (ms *MockServer) URL() string {
+	if ms.url == nil {
+		return ""
+	}
+	return ms.url.String()
+}
+
+// AddMockups registers mocks in the mock server.
+//
+// Many mocks can share the same HTTP method and URL, as long as they differ on
+// their request headers or body. When more than one mock matches a request,
+// the most specific one is used. On a tie, the last one added wins.
+// Mocks matching the exact URL and query params are preferred over the
+// URLPattern, URLRegexp or QueryMatch ones.
+func (ms *MockServer) AddMockups(mocks ...*Mock) error {
+	for _, m := range mocks {
+
+		if m.Template {
+			if err := m.parseTemplates(); err != nil {
+				return fmt.Errorf("Error parsing mock template with url=%s. Cause: %s", m.URL, err.Error())
+			}
+		}
+
+		if m.URLMatch != URLExact || m.QueryMatch != QueryExact {
+			um, err := newURLMatcher(m)
+			if err != nil {
+				return fmt.Errorf("Error parsing mock with url=%s. Cause: %s", m.URL, err.Error())
+			}
+			ms.mutex.Lock()
+			delete(ms.uses, m)
+			ms.matchers = append(ms.matchers, um)
+			ms.mutex.Unlock()
+			continue
+		}
+
+		normalizedURL, err := getNormalizedURL(m.URL)
+		if err != nil {
+			return fmt.Errorf("Error parsing mock with url=%s. Cause: %s", m.URL, err.Error())
+		}
+		key := m.HTTPMethod + " " + normalizedURL
+		ms.mutex.Lock()
+		delete(ms.uses, m)
+		ms.mocks[key] = append(ms.mocks[key], m)
+		ms.mutex.Unlock()
+	}
+	return nil
+}
+
+// removeMockups removes the given mocks, keeping any other
+func (ms *MockServer) removeMockups(mocks ...*Mock) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for _, m := range mocks {
+		delete(ms.uses, m)
+
+		if m.URLMatch != URLExact || m.QueryMatch != QueryExact {
+			for i, um := range ms.matchers {
+				if um.mock == m {
+					ms.matchers = append(ms.matchers[:i:i], ms.matchers[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+
+		normalizedURL, err := getNormalizedURL(m.URL)
+		if err != nil {
+			continue
+		}
+
+		key := m.HTTPMethod + " " + normalizedURL
+		list := ms.mocks[key]
+
+		for i := range list {
+			if list[i] == m {
+				list = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+
+		if len(list) == 0 {
+			delete(ms.mocks, key)
+		} else {
+			ms.mocks[key] = list
+		}
+	}
+}
+
+// FlushMockups removes every mock, resets every scenario to ScenarioStarted
+// and clears the recorded calls.
+func (ms *MockServer) FlushMockups() {
+	ms.mutex.Lock()
+	ms.mocks = make(map[string][]*Mock)
+	ms.matchers = nil
+	ms.uses = make(map[*Mock]int)
+	ms.scenarios = make(map[string]string)
+	ms.calls = nil
+	ms.mutex.Unlock()
+}
+
+// Calls returns every request received by the mock server, in order.
+func (ms *MockServer) Calls() []MockCall {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	return append([]MockCall(nil), ms.calls...)
+}
+
+// ResetCalls clears the recorded calls, keeping the mocks.
+func (ms *MockServer) ResetCalls() {
+	ms.mutex.Lock()
+	ms.calls = nil
+	ms.mutex.Unlock()
+}
+
+// UnmatchedCalls returns the requests that didn't match any mock.
+func (ms *MockServer) UnmatchedCalls() []MockCall {
+	return ms.CallsOf(nil)
+}
+
+// CallsOf returns the requests served by the mock m.
+func (ms *MockServer) CallsOf(m *Mock) []MockCall {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var calls []MockCall
+	for _, c := range ms.calls {
+		if c.Mock == m {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// VerifyCalls returns an error unless the mock m served exactly times requests.
+//
+//	if err := ms.VerifyCalls(mock, 2); err != nil {
+//		t.Fatal(err)
+//	}
+func (ms *MockServer) VerifyCalls(m *Mock, times int) error {
+	if n := len(ms.CallsOf(m)); n != times {
+		return fmt.Errorf("mock %s %s was called %d times, expected %d", m.HTTPMethod, m.URL, n, times)
+	}
+	return nil
+}
+
+// VerifyNoUnmatchedCalls returns an error if any request didn't match a mock.
+func (ms *MockServer) VerifyNoUnmatchedCalls() error {
+	calls := ms.UnmatchedCalls()
+	if len(calls) == 0 {
+		return nil
+	}
+
+	unmatched := make([]string, len(calls))
+	for i, c := range calls {
+		unmatched[i] = c.Method + " " + c.URL
+	}
+
+	return fmt.Errorf("%d unmatched mock calls: %s", len(calls), strings.Join(unmatched, ", "))
+}
+
+// ScenarioState returns the current state of a mock scenario.
+func (ms *MockServer) ScenarioState(scenario string) string {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	return ms.scenarioState(scenario)
+}
+
+// Lock must be held by the caller
+func (ms *MockServer) scenarioState(scenario string) string {
+	if state, ok := ms.scenarios[scenario]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+// route changes reqURL to point to the mock server
+func (ms *MockServer) route(reqURL string) (string, error) {
+
+	if ms.url == nil {
+		return reqURL, errors.New("mock server is closed")
+	}
+
+	rURL, err := url.Parse(reqURL)
+	if err != nil {
+		return reqURL, err
+	}
+
+	rURL.Scheme = ms.url.Scheme
+	rURL.Host = ms.url.Host
+
+	return rURL.String(), nil
+}
+
+func (ms *MockServer) handler(writer http.ResponseWriter, req *http.Request) {
+
+	originalURL := req.Header.Get("X-Original-URL")
+	body, _ := ioutil.ReadAll(req.Body)
+
+	call := MockCall{
+		Method: req.Method,
+		URL:    originalURL,
+		Header: req.Header.Clone(),
+		Body:   body,
+	}
+
+	normalizedURL, err := getNormalizedURL(originalURL)
+
+	if err == nil {
+		// Full lock, using a mock changes its state
+		ms.mutex.Lock()
+		m := ms.bestMock(ms.mocks[req.Method+" "+normalizedURL], req, body)
+		if m == nil {
+			m, call.Vars = ms.bestMatcherMock(req, originalURL, body)
+		}
+		var resp MockResponse
+		if m != nil {
+			resp = ms.use(m)
+		}
+		call.Mock = m
+		ms.calls = append(ms.calls, call)
+		cassette := ms.cassette
+		ms.mutex.Unlock()
+
+		// Send it to the real target
+		if m == nil && cassette != nil && cassette.Mode == CassetteRecord {
+			cassette.record(writer, call)
+			return
+		}
+
+		if m != nil {
+			respond(writer, req, m, resp, call)
+			return
+		}
+	}
+
+	if err != nil {
+		ms.mutex.Lock()
+		ms.calls = append(ms.calls, call)
+		ms.mutex.Unlock()
+	}
+
+	writer.Header().Set(mockNotFoundHeader, "true")
+	writer.WriteHeader(http.StatusBadRequest)
+	writer.Write([]byte(MockNotFoundError))
+}
+
+// bestMock returns the most specific mock matching the request, or nil.
+// Lock must be held by the caller.
+func (ms *MockServer) bestMock(mocks []*Mock, req *http.Request, body []byte) *Mock {
+
+	var best *Mock
+	bestScore := -1
+
+	for _, m := range mocks {
+		if score, ok := ms.match(m, req, body); ok && score >= bestScore {
+			best = m
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// bestMatcherMock returns the most specific mock, among the ones with
+// an urlMatcher, matching the request, and the variables it captured.
+// Lock must be held by the caller.
+func (ms *MockServer) bestMatcherMock(req *http.Request, reqURL string, body []byte) (*Mock, map[string]string) {
+
+	var best *Mock
+	var bestVars map[string]string
+	bestScore := -1
+
+	for _, um := range ms.matchers {
+		if um.mock.HTTPMethod != req.Method {
+			continue
+		}
+
+		vars, ok := um.match(reqURL)
+		if !ok {
+			continue
+		}
+
+		if score, ok := ms.match(um.mock, req, body); ok && score >= bestScore {
+			best = um.mock
+			bestVars = vars
+			bestScore = score
+		}
+	}
+
+	return best, bestVars
+}
+
+// match reports if the request matches the mock headers, body and
+// scenario state, and how specific the match is: one point per header
+// value, one for the body and one for the state.
+// Lock must be held by the caller.
+func (ms *MockServer) match(m *Mock, req *http.Request, body []byte) (score int, ok bool) {
+
+	if m.Times > 0 && ms.uses[m] >= m.Times {
+		return 0, false
+	}
+
+	if m.RequiredState != "" {
+		if ms.scenarioState(m.Scenario) != m.RequiredState {
+			return 0, false
+		}
+		score++
+	}
+
+	for k, values := range m.ReqHeaders {
+		reqValues := req.Header.Values(k)
+
+		for _, v := range values {
+			if !containsString(reqValues, v) {
+				return 0, false
+			}
+			score++
+		}
+	}
+
+	switch {
+	case m.ReqBodyMatcher != nil:
+		if !m.ReqBodyMatcher(body) {
+			return 0, false
+		}
+		score++
+
+	case m.ReqBody != "":
+		if !m.matchBody(body) {
+			return 0, false
+		}
+		score++
+	}
+
+	return score, true
+}
+
+// use returns the response for the current call of m, and moves it
+// to the next one. Full lock must be held by the caller.
+func (ms *MockServer) use(m *Mock) MockResponse {
+
+	resp := MockResponse{
+		HTTPCode: m.RespHTTPCode,
+		Headers:  m.RespHeaders,
+		Body:     m.RespBody,
+	}
+
+	calls := ms.uses[m]
+
+	if n := len(m.Responses); n > 0 {
+		if calls < n {
+			resp = m.Responses[calls]
+		} else {
+			resp = m.Responses[n-1]
+		}
+	}
+
+	ms.uses[m] = calls + 1
+
+	if m.NewState != "" {
+		ms.scenarios[m.Scenario] = m.NewState
+	}
+
+	return resp
+}
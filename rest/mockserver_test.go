@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestMockServerIsolation(t *testing.T) {
+
+	myURL := "http://mytest.com/isolated"
+
+	for i := 0; i < 5; i++ {
+		body := strconv.Itoa(i)
+
+		t.Run(body, func(t *testing.T) {
+			t.Parallel()
+
+			ms := NewMockServer()
+			t.Cleanup(ms.Close)
+
+			ms.AddMockups(&Mock{
+				URL:          myURL,
+				HTTPMethod:   http.MethodGet,
+				RespHTTPCode: http.StatusOK,
+				RespBody:     body,
+			})
+
+			builder := RequestBuilder{MockServer: ms}
+
+			for j := 0; j < 10; j++ {
+				if r := builder.Get(myURL); r.String() != body {
+					t.Fatalf("Expected %s, got %s", body, r.String())
+				}
+			}
+
+			if len(ms.Calls()) != 10 {
+				t.Fatal("Should have recorded 10 calls")
+			}
+		})
+	}
+}
+
+func TestMockServerContext(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	myURL := "http://mytest.com/context"
+
+	mock := &Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "foo",
+	}
+
+	ms.AddMockups(mock)
+
+	ctx := WithMockServer(context.Background(), ms)
+
+	var f [3]*FutureResponse
+
+	ForkJoinCtx(ctx, func(c *Concurrent) {
+		for i := range f {
+			f[i] = c.Get(myURL)
+		}
+	})
+
+	for i := range f {
+		if f[i].Response().String() != "foo" {
+			t.Fatal("Mockup Fail!")
+		}
+	}
+
+	if err := ms.VerifyCalls(mock, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Global mockup server knows nothing about it
+	if len(MockCalls()) != 0 {
+		t.Fatal("Global mockup server should not get any call")
+	}
+}
+
+func TestMockServerClosed(t *testing.T) {
+
+	ms := NewMockServer()
+	ms.Close()
+
+	builder := RequestBuilder{MockServer: ms}
+
+	if r := builder.Get("http://mytest.com/closed"); r.Err == nil {
+		t.Fatal("Closed mock server should get an error")
+	}
+}
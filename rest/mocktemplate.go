@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// MockRequest is the data of the templates of a Mock
+//
+//	{{.Vars.id}}              a variable captured from the URL
+//	{{.Query.Get "page"}}     a query param
+//	{{.Header.Get "X-Name"}}  a request header
+//	{{.JSON.user.name}}       a field of the JSON request body
+//
+// Besides the template builtins, these functions are available:
+//
+//	{{json .JSON.user}}         the value as JSON
+//	{{default "1" .Vars.page}}  the value, or "1" when it is empty
+//	{{range seq 3}}...{{end}}   loops over 0, 1 & 2
+type MockRequest struct {
+	Method string
+	URL    string
+	Vars   map[string]string
+	Query  url.Values
+	Header http.Header
+	Body   string
+
+	// The request body decoded as JSON, nil if it isn't JSON
+	JSON interface{}
+}
+
+var mockTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"default": func(def interface{}, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"seq": func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		return s
+	},
+}
+
+func newMockRequest(call MockCall) *MockRequest {
+
+	mr := &MockRequest{
+		Method: call.Method,
+		URL:    call.URL,
+		Vars:   call.Vars,
+		Header: call.Header,
+		Body:   string(call.Body),
+	}
+
+	if u, err := url.Parse(call.URL); err == nil {
+		mr.Query = u.Query()
+	}
+
+	if json.Unmarshal(call.Body, &mr.JSON) != nil {
+		mr.JSON = nil
+	}
+
+	return mr
+}
+
+// renderMockResponse returns resp with its body & headers rendered
+func renderMockResponse(resp MockResponse, call MockCall) (MockResponse, error) {
+
+	data := newMockRequest(call)
+
+	body, err := renderMockTemplate(resp.Body, data)
+	if err != nil {
+		return resp, err
+	}
+
+	rendered := MockResponse{HTTPCode: resp.HTTPCode, Body: body}
+
+	if resp.Headers != nil {
+		rendered.Headers = make(http.Header, len(resp.Headers))
+	}
+
+	for k, values := range resp.Headers {
+		for _, v := range values {
+			if v, err = renderMockTemplate(v, data); err != nil {
+				return resp, err
+			}
+			rendered.Headers[k] = append(rendered.Headers[k], v)
+		}
+	}
+
+	return rendered, nil
+}
+
+func renderMockTemplate(text string, data *MockRequest) (string, error) {
+
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	t, err := parseMockTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+func parseMockTemplate(text string) (*template.Template, error) {
+	return template.New("mock").Funcs(mockTemplateFuncs).Parse(text)
+}
+
+// parseTemplates checks the syntax of every mock template
+func (m *Mock) parseTemplates() error {
+
+	responses := append([]MockResponse{{Headers: m.RespHeaders, Body: m.RespBody}}, m.Responses...)
+
+	for _, resp := range responses {
+		if _, err := parseMockTemplate(resp.Body); err != nil {
+			return err
+		}
+
+		for _, values := range resp.Headers {
+			for _, v := range values {
+				if _, err := parseMockTemplate(v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMockupTemplate(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users/{id}",
+		URLMatch:     URLPattern,
+		QueryMatch:   QueryIgnore,
+		HTTPMethod:   http.MethodPut,
+		Template:     true,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders: http.Header{
+			"Content-Type": {"application/json"},
+			"X-Trace":      {`{{.Header.Get "X-Trace"}}`},
+		},
+		RespBody: `{"id":{{.Vars.id}},"name":{{json .JSON.name}},"lang":"{{default "en" (.Query.Get "lang")}}"}`,
+	}, &Mock{
+		URL:          "http://mytest.com/numbers",
+		HTTPMethod:   http.MethodGet,
+		Template:     true,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     `{{range seq 3}}{{.}}{{end}}`,
+	})
+
+	builder := RequestBuilder{MockServer: ms, Headers: http.Header{"X-Trace": {"abc"}}}
+
+	var u User
+	r := builder.Put("http://mytest.com/users/7?lang=es", &User{Name: "Hernan"})
+	if r.Err != nil || r.Header.Get("X-Trace") != "abc" {
+		t.Fatalf("Unexpected response %v, error: %v", r.Header, r.Err)
+	}
+
+	if r.String() != `{"id":7,"name":"Hernan","lang":"es"}` {
+		t.Fatalf("Unexpected body %s", r.String())
+	}
+
+	if err := r.FillUp(&u); err != nil || u.ID != 7 {
+		t.Fatalf("Unexpected user %+v, error: %v", u, err)
+	}
+
+	if r := builder.Put("http://mytest.com/users/8", &User{Name: "Max"}); !strings.Contains(r.String(), `"lang":"en"`) {
+		t.Fatalf("Unexpected body %s", r.String())
+	}
+
+	if r := builder.Get("http://mytest.com/numbers"); r.String() != "012" {
+		t.Fatalf("Unexpected body %s", r.String())
+	}
+
+	err := ms.AddMockups(&Mock{
+		URL:        "http://mytest.com/broken",
+		HTTPMethod: http.MethodGet,
+		Template:   true,
+		RespBody:   "{{.Vars.id",
+	})
+	if err == nil {
+		t.Fatal("Invalid template should get an error")
+	}
+}
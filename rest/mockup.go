@@ -1,26 +1,23 @@
 package rest
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"sort"
 	"strings"
-	"sync"
+	"time"
 )
 
 // MockNotFoundError ...
 const MockNotFoundError string = "MockUp nil!"
 
 var mockUpEnv bool
-var mockMap = make(map[string]*Mock)
-var mockDbMutex sync.RWMutex
 
-var mockServer *httptest.Server
-var mux *http.ServeMux
-
-var mockServerURL *url.URL
+// The mock server behind the package-level mockup functions
+var mockServer = newMockServer()
 
 // Mock serves the purpose of creating Mockups.
 // All requests will be sent to the mockup server if mockup is activated.
@@ -33,35 +30,153 @@ type Mock struct {
 	// Request URL
 	URL string
 
+	// How URL is compared with the request URL. Default: URLExact
+	URLMatch URLMatch
+
+	// How the URL query params are compared with the request ones.
+	// Default: QueryExact
+	QueryMatch QueryMatch
+
 	// Request HTTP Method (GET, POST, PUT, PATCH, HEAD, DELETE, OPTIONS)
 	// As a good practice use the constants in http package (http.MethodGet, etc.)
 	HTTPMethod string
 
-	// Request array Headers
+	// Request array Headers.
+	// If set, the request must have (at least) all of them to match the mock.
 	ReqHeaders http.Header
 
-	// Request Body, used with POST, PUT & PATCH
+	// Request Body, used with POST, PUT & PATCH.
+	// If set, the request body must match it as defined by ReqBodyMatch.
 	ReqBody string
 
+	// How ReqBody is compared with the request body. Default: BodyExact
+	ReqBodyMatch BodyMatch
+
+	// ReqBodyMatcher let you decide if a request body matches the mock.
+	// When set, ReqBody is ignored.
+	ReqBodyMatcher func(body []byte) bool
+
 	// Response HTTP Code
 	RespHTTPCode int
 
-	// Response Array Headers
+	// Response Array Headers.
+	// Values may reference the variables captured from the URL as {name}.
 	RespHeaders http.Header
 
 	// Response Body
 	RespBody string
+
+	// Template renders the response body & header values, including the
+	// Responses ones, as Go templates with the request as data, see MockRequest.
+	//	RespBody: `{"id": {{.Vars.id}}, "name": {{json .JSON.name}}}`
+	Template bool
+
+	// Responses let you return a different response on each call, in order.
+	// Once exhausted, the last one is returned over and over again.
+	// When set, RespHTTPCode, RespHeaders & RespBody are ignored.
+	Responses []MockResponse
+
+	// Times limits how many requests the mock will match. Zero means unlimited.
+	Times int
+
+	// Scenario groups mocks sharing a state, which starts as ScenarioStarted.
+	Scenario string
+
+	// If set, the mock only matches when its Scenario is in this state.
+	RequiredState string
+
+	// If set, the Scenario moves to this state after the mock is used.
+	NewState string
+
+	// Delay waits before sending the response headers.
+	Delay time.Duration
+
+	// BodyDelay waits between sending the response headers and the body.
+	BodyDelay time.Duration
+
+	// Fault breaks the response on purpose. Default: FaultNone
+	Fault Fault
+}
+
+// MockResponse is one of the responses of a sequenced Mock
+type MockResponse struct {
+	HTTPCode int
+	Headers  http.Header
+	Body     string
+}
+
+// ScenarioStarted is the initial state of every mock Scenario
+const ScenarioStarted = "Started"
+
+// MockCall is a request received by the mockup server
+type MockCall struct {
+
+	// Request HTTP Method
+	Method string
+
+	// Original request URL, before being routed to the mockup server
+	URL string
+
+	// Request Headers
+	Header http.Header
+
+	// Request Body
+	Body []byte
+
+	// The mock that served the request, nil if none matched
+	Mock *Mock
+
+	// Variables captured from the request URL by a URLPattern or URLRegexp mock
+	Vars map[string]string
 }
 
+// BodyMatch defines how Mock.ReqBody is compared with the request body
+type BodyMatch int
+
+const (
+	// BodyExact matches when both bodies are byte to byte equal
+	BodyExact BodyMatch = iota
+	// BodyJSON matches when both bodies are semantically equal JSON documents,
+	// regardless of keys order and whitespaces
+	BodyJSON
+)
+
+// URLMatch defines how Mock.URL is compared with the request URL
+type URLMatch int
+
+const (
+	// URLExact matches when both URLs are equal, regardless of query params order
+	URLExact URLMatch = iota
+	// URLPattern matches the request URL, without its query params, against
+	// a pattern where {name} matches a path segment and captures it as
+	// the variable name, * matches anything within a segment and ** matches anything.
+	//	http://api.example.com/users/{id}/*
+	URLPattern
+	// URLRegexp matches the whole request URL, query params included, against
+	// a regular expression. Named groups are captured as variables.
+	// QueryMatch is ignored.
+	URLRegexp
+)
+
+// QueryMatch defines how the query params of Mock.URL are compared
+// with the request ones
+type QueryMatch int
+
+const (
+	// QueryExact matches when both have the same query params, in any order
+	QueryExact QueryMatch = iota
+	// QueryIgnore matches any query params
+	QueryIgnore
+	// QuerySubset matches when the request has, at least, the mock query params
+	QuerySubset
+)
+
 // StartMockupServer sets the enviroment to send all client requests
 // to the mockup server.
 func StartMockupServer() {
 
 	mockUpEnv = true
-
-	if mockServer == nil {
-		startMockupServ()
-	}
+	mockServer.start()
 }
 
 // StopMockupServer stop sending requests to the mockup server
@@ -69,40 +184,15 @@ func StopMockupServer() {
 
 	mockUpEnv = false
 	mockServer.Close()
-
-	mockServer = nil
-	mockServerURL = nil
-	mux = nil
-}
-
-func startMockupServ() {
-
-	if mockUpEnv {
-		mux = http.NewServeMux()
-		mockServer = httptest.NewServer(mux)
-		mux.HandleFunc("/", mockupHandler)
-		mockDbMutex = *new(sync.RWMutex)
-
-		var err error
-		if mockServerURL, err = url.Parse(mockServer.URL); err != nil {
-			panic(err)
-		}
-
-	}
 }
 
-// AddMockups ...
+// AddMockups registers mocks in the mockup server.
+//
+// Many mocks can share the same HTTP method and URL, as long as they differ on
+// their request headers or body. When more than one mock matches a request,
+// the most specific one is used. On a tie, the last one added wins.
 func AddMockups(mocks ...*Mock) error {
-	for _, m := range mocks {
-		normalizedURL, err := getNormalizedURL(m.URL)
-		if err != nil {
-			return fmt.Errorf("Error parsing mock with url=%s. Cause: %s", m.URL, err.Error())
-		}
-		mockDbMutex.Lock()
-		mockMap[m.HTTPMethod+" "+normalizedURL] = m
-		mockDbMutex.Unlock()
-	}
-	return nil
+	return mockServer.AddMockups(mocks...)
 }
 
 //check if a string url is valid and also sort query params in order to make the url easy to compare
@@ -135,35 +225,74 @@ func getNormalizedURL(urlStr string) (string, error) {
 	return result, nil
 }
 
-// FlushMockups ...
+// FlushMockups removes every mock, resets every scenario to ScenarioStarted
+// and clears the recorded calls.
 func FlushMockups() {
-	mockDbMutex.Lock()
-	mockMap = make(map[string]*Mock)
-	mockDbMutex.Unlock()
+	mockServer.FlushMockups()
+}
+
+// MockCalls returns every request received by the mockup server, in order.
+func MockCalls() []MockCall {
+	return mockServer.Calls()
 }
 
-func mockupHandler(writer http.ResponseWriter, req *http.Request) {
+// ResetMockCalls clears the recorded calls, keeping the mocks.
+func ResetMockCalls() {
+	mockServer.ResetCalls()
+}
 
-	normalizedURL, err := getNormalizedURL(req.Header.Get("X-Original-URL"))
+// UnmatchedMockCalls returns the requests that didn't match any mock.
+func UnmatchedMockCalls() []MockCall {
+	return mockServer.UnmatchedCalls()
+}
 
-	if err == nil {
-		mockDbMutex.RLock()
-		m := mockMap[req.Method+" "+normalizedURL]
-		mockDbMutex.RUnlock()
-		if m != nil {
-			// Add headers
-			for k, v := range m.RespHeaders {
-				for _, vv := range v {
-					writer.Header().Add(k, vv)
-				}
-			}
+// CallsOf returns the requests served by the mock m.
+func CallsOf(m *Mock) []MockCall {
+	return mockServer.CallsOf(m)
+}
+
+// VerifyMockCalls returns an error unless the mock m served exactly times requests.
+//
+//	if err := rest.VerifyMockCalls(mock, 2); err != nil {
+//		t.Fatal(err)
+//	}
+func VerifyMockCalls(m *Mock, times int) error {
+	return mockServer.VerifyCalls(m, times)
+}
+
+// VerifyNoUnmatchedMockCalls returns an error if any request didn't match a mock.
+func VerifyNoUnmatchedMockCalls() error {
+	return mockServer.VerifyNoUnmatchedCalls()
+}
+
+// MockScenarioState returns the current state of a mock scenario.
+func MockScenarioState(scenario string) string {
+	return mockServer.ScenarioState(scenario)
+}
+
+func (m *Mock) matchBody(body []byte) bool {
+
+	switch m.ReqBodyMatch {
+	case BodyJSON:
+		var expected, actual interface{}
+
+		if json.Unmarshal([]byte(m.ReqBody), &expected) != nil || json.Unmarshal(body, &actual) != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(expected, actual)
+
+	default:
+		return m.ReqBody == string(body)
+	}
+}
 
-			writer.WriteHeader(m.RespHTTPCode)
-			writer.Write([]byte(m.RespBody))
-			return
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
 		}
 	}
 
-	writer.WriteHeader(http.StatusBadRequest)
-	writer.Write([]byte(MockNotFoundError))
+	return false
 }
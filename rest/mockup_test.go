@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"bytes"
 	"net/http"
 	"testing"
 )
@@ -25,9 +26,404 @@ func TestMockup(t *testing.T) {
 
 	AddMockups(&mock)
 
-	v := Get(myURL)
+	builder := RequestBuilder{Headers: myHeaders}
+
+	v := builder.Get(myURL)
 	if v.String() != "foo" {
 		t.Fatal("Mockup Fail!")
 	}
 
+	v = Get(myURL)
+	if v.String() != MockNotFoundError {
+		t.Fatal("Mockup should not match without its request headers")
+	}
+
+}
+
+func TestMockupMatchHeaders(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	myURL := "http://mytest.com/headers"
+
+	AddMockups(
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "any",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			ReqHeaders:   http.Header{"X-Tenant": []string{"a"}},
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "tenant a",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			ReqHeaders:   http.Header{"X-Tenant": []string{"a"}, "X-Role": []string{"admin"}},
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "tenant a admin",
+		},
+	)
+
+	tests := []struct {
+		headers  http.Header
+		expected string
+	}{
+		{nil, "any"},
+		{http.Header{"X-Tenant": []string{"b"}}, "any"},
+		{http.Header{"X-Tenant": []string{"a"}}, "tenant a"},
+		{http.Header{"X-Tenant": []string{"a"}, "X-Role": []string{"admin"}}, "tenant a admin"},
+	}
+
+	for _, test := range tests {
+		builder := RequestBuilder{Headers: test.headers}
+
+		if v := builder.Get(myURL); v.String() != test.expected {
+			t.Fatalf("Expected %s, got %s", test.expected, v.String())
+		}
+	}
+}
+
+func TestMockupMatchBody(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	myURL := "http://mytest.com/body"
+
+	AddMockups(
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodPost,
+			ReqBody:      `{"id":0,"name":"Matilda"}`,
+			RespHTTPCode: http.StatusCreated,
+			RespBody:     "exact",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodPost,
+			ReqBody:      `{ "name": "Pichucha", "id": 0 }`,
+			ReqBodyMatch: BodyJSON,
+			RespHTTPCode: http.StatusCreated,
+			RespBody:     "json",
+		},
+		&Mock{
+			URL:        myURL,
+			HTTPMethod: http.MethodPost,
+			ReqBodyMatcher: func(body []byte) bool {
+				return bytes.Contains(body, []byte("Felicitas"))
+			},
+			RespHTTPCode: http.StatusCreated,
+			RespBody:     "custom",
+		},
+	)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Matilda", "exact"},
+		{"Pichucha", "json"},
+		{"Felicitas", "custom"},
+		{"Max", MockNotFoundError},
+	}
+
+	for _, test := range tests {
+		if v := Post(myURL, &User{Name: test.name}); v.String() != test.expected {
+			t.Fatalf("Expected %s, got %s", test.expected, v.String())
+		}
+	}
+}
+
+func TestMockupResponsesSequence(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	myURL := "http://mytest.com/sequence"
+
+	AddMockups(&Mock{
+		URL:        myURL,
+		HTTPMethod: http.MethodGet,
+		Responses: []MockResponse{
+			{HTTPCode: http.StatusServiceUnavailable},
+			{HTTPCode: http.StatusServiceUnavailable},
+			{HTTPCode: http.StatusOK, Body: "foo"},
+		},
+	})
+
+	for _, expected := range []int{503, 503, 200, 200} {
+		if v := Get(myURL); v.StatusCode != expected {
+			t.Fatalf("Expected %d, got %d", expected, v.StatusCode)
+		}
+	}
+}
+
+func TestMockupTimes(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	myURL := "http://mytest.com/times"
+
+	AddMockups(&Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "foo",
+		Times:        2,
+	})
+
+	for _, expected := range []string{"foo", "foo", MockNotFoundError} {
+		if v := Get(myURL); v.String() != expected {
+			t.Fatalf("Expected %s, got %s", expected, v.String())
+		}
+	}
+}
+
+func TestMockupScenario(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	usersURL := "http://mytest.com/users"
+	userURL := "http://mytest.com/users/1"
+
+	AddMockups(
+		&Mock{
+			URL:          userURL,
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusNotFound,
+		},
+		&Mock{
+			URL:          usersURL,
+			HTTPMethod:   http.MethodPost,
+			RespHTTPCode: http.StatusCreated,
+			Scenario:     "users",
+			NewState:     "created",
+		},
+		&Mock{
+			URL:           userURL,
+			HTTPMethod:    http.MethodGet,
+			RespHTTPCode:  http.StatusOK,
+			RespBody:      `{"id":1,"name":"Matilda"}`,
+			Scenario:      "users",
+			RequiredState: "created",
+		},
+	)
+
+	if v := Get(userURL); v.StatusCode != http.StatusNotFound {
+		t.Fatal("Status != Not Found (404)")
+	}
+
+	if MockScenarioState("users") != ScenarioStarted {
+		t.Fatal("Scenario should be started")
+	}
+
+	if v := Post(usersURL, &User{Name: "Matilda"}); v.StatusCode != http.StatusCreated {
+		t.Fatal("Status != Created (201)")
+	}
+
+	if MockScenarioState("users") != "created" {
+		t.Fatal("Scenario should be created")
+	}
+
+	if v := Get(userURL); v.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+}
+
+func TestMockupCalls(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	myURL := "http://mytest.com/calls"
+
+	mock := &Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodPost,
+		RespHTTPCode: http.StatusCreated,
+	}
+
+	AddMockups(mock)
+
+	Post(myURL, &User{Name: "Matilda"})
+	Post(myURL, &User{Name: "Pichucha"})
+
+	if err := VerifyMockCalls(mock, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyNoUnmatchedMockCalls(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := CallsOf(mock)
+	if calls[1].URL != myURL || string(calls[1].Body) != `{"id":0,"name":"Pichucha"}` {
+		t.Fatalf("Unexpected call %+v", calls[1])
+	}
+
+	Get("http://mytest.com/unknown")
+
+	if err := VerifyNoUnmatchedMockCalls(); err == nil {
+		t.Fatal("Should have unmatched calls")
+	}
+
+	if len(MockCalls()) != 3 {
+		t.Fatal("Should have recorded 3 calls")
+	}
+
+	ResetMockCalls()
+
+	if len(MockCalls()) != 0 {
+		t.Fatal("Calls should be reset")
+	}
+}
+
+func TestMockupURLPattern(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	AddMockups(&Mock{
+		URL:          "http://mytest.com/users/{id}",
+		URLMatch:     URLPattern,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  http.Header{"X-User": {"{id}"}},
+		RespBody:     "user",
+	}, &Mock{
+		URL:          "http://mytest.com/users/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "exact",
+	}, &Mock{
+		URL:          "http://mytest.com/files/**",
+		URLMatch:     URLPattern,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "file",
+	}, &Mock{
+		URL:          "http://*.mytest.com/status",
+		URLMatch:     URLPattern,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "status",
+	})
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://mytest.com/users/1", "exact"},
+		{"http://mytest.com/users/42", "user"},
+		{"http://mytest.com/users/42/friends", MockNotFoundError},
+		{"http://mytest.com/users/42?foo=bar", MockNotFoundError},
+		{"http://mytest.com/files/a/b/c.txt", "file"},
+		{"http://api.mytest.com/status", "status"},
+		{"http://mytest.com/status", MockNotFoundError},
+	}
+
+	for _, test := range tests {
+		if v := Get(test.url); v.String() != test.expected {
+			t.Fatalf("%s: expected %s, got %s", test.url, test.expected, v.String())
+		}
+	}
+
+	if v := Get("http://mytest.com/users/42"); v.Header.Get("X-User") != "42" {
+		t.Fatalf("Expected X-User 42, got %s", v.Header.Get("X-User"))
+	}
+
+	calls := MockCalls()
+	if vars := calls[len(calls)-1].Vars; vars["id"] != "42" {
+		t.Fatalf("Expected id var 42, got %v", vars)
+	}
+}
+
+func TestMockupURLRegexp(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	AddMockups(&Mock{
+		URL:          `http://mytest\.com/orders/(?P<id>\d+)(\?.*)?`,
+		URLMatch:     URLRegexp,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  http.Header{"Location": {"/orders/{id}"}},
+	})
+
+	v := Get("http://mytest.com/orders/7?expand=items")
+	if v.StatusCode != http.StatusOK || v.Header.Get("Location") != "/orders/7" {
+		t.Fatalf("Unexpected response %d %v", v.StatusCode, v.Header)
+	}
+
+	if v := Get("http://mytest.com/orders/seven"); v.String() != MockNotFoundError {
+		t.Fatal("Non numeric ids should not match")
+	}
+
+	if err := AddMockups(&Mock{URL: "(", URLMatch: URLRegexp, HTTPMethod: http.MethodGet}); err == nil {
+		t.Fatal("Invalid regexp should get an error")
+	}
+}
+
+func TestMockupQueryMatch(t *testing.T) {
+
+	defer StopMockupServer()
+	defer FlushMockups()
+	StartMockupServer()
+
+	AddMockups(&Mock{
+		URL:          "http://mytest.com/search?q=go",
+		QueryMatch:   QuerySubset,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "subset",
+	}, &Mock{
+		URL:          "http://mytest.com/list",
+		QueryMatch:   QueryIgnore,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "ignore",
+	}, &Mock{
+		URL:          "http://mytest.com/items/{id}?fields=name",
+		URLMatch:     URLPattern,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "pattern",
+	})
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://mytest.com/search?q=go", "subset"},
+		{"http://mytest.com/search?page=2&q=go", "subset"},
+		{"http://mytest.com/search?q=rust", MockNotFoundError},
+		{"http://mytest.com/search", MockNotFoundError},
+		{"http://mytest.com/list", "ignore"},
+		{"http://mytest.com/list?page=3", "ignore"},
+		{"http://mytest.com/items/1?fields=name", "pattern"},
+		{"http://mytest.com/items/1", MockNotFoundError},
+	}
+
+	for _, test := range tests {
+		if v := Get(test.url); v.String() != test.expected {
+			t.Fatalf("%s: expected %s, got %s", test.url, test.expected, v.String())
+		}
+	}
 }
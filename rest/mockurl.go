@@ -0,0 +1,168 @@
+package rest
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlMatcher matches request URLs against a mock which can't be found
+// by its normalized URL: URLPattern, URLRegexp or not requiring the
+// exact query params.
+type urlMatcher struct {
+	mock  *Mock
+	re    *regexp.Regexp
+	query url.Values
+}
+
+func newURLMatcher(m *Mock) (*urlMatcher, error) {
+
+	um := &urlMatcher{mock: m}
+
+	if m.URLMatch == URLRegexp {
+		re, err := regexp.Compile("^(?:" + m.URL + ")$")
+		if err != nil {
+			return nil, err
+		}
+		um.re = re
+		return um, nil
+	}
+
+	base, rawQuery := splitQuery(m.URL)
+
+	expr := regexp.QuoteMeta(base)
+	if m.URLMatch == URLPattern {
+		var err error
+		if expr, err = patternToRegexp(base); err != nil {
+			return nil, err
+		}
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	um.re = re
+	um.query = query
+
+	return um, nil
+}
+
+// match reports if reqURL matches, and returns the captured variables
+func (um *urlMatcher) match(reqURL string) (map[string]string, bool) {
+
+	target, rawQuery := reqURL, ""
+	if um.mock.URLMatch != URLRegexp {
+		target, rawQuery = splitQuery(reqURL)
+	}
+
+	sm := um.re.FindStringSubmatch(target)
+	if sm == nil {
+		return nil, false
+	}
+
+	if um.mock.URLMatch != URLRegexp && !um.matchQuery(rawQuery) {
+		return nil, false
+	}
+
+	var vars map[string]string
+	for i, name := range um.re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if vars == nil {
+			vars = make(map[string]string)
+		}
+		vars[name] = sm[i]
+	}
+
+	return vars, true
+}
+
+func (um *urlMatcher) matchQuery(rawQuery string) bool {
+
+	if um.mock.QueryMatch == QueryIgnore {
+		return true
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return false
+	}
+
+	if um.mock.QueryMatch == QuerySubset {
+		for k, values := range um.query {
+			for _, v := range values {
+				if !containsString(query[k], v) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	// Encode sorts by key
+	return query.Encode() == um.query.Encode()
+}
+
+// patternToRegexp translates a URLPattern into a regular expression
+func patternToRegexp(pattern string) (string, error) {
+
+	var sb strings.Builder
+
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i += 2
+
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+			i++
+
+		case pattern[i] == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return "", errors.New("unclosed { in url pattern")
+			}
+			sb.WriteString("(?P<" + pattern[i+1:i+end] + ">[^/]+)")
+			i += end + 1
+
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			i++
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// splitQuery splits an URL into the URL without query, and the raw query
+func splitQuery(rawURL string) (string, string) {
+	if i := strings.IndexByte(rawURL, '?'); i >= 0 {
+		return rawURL[:i], rawURL[i+1:]
+	}
+	return rawURL, ""
+}
+
+// expandVars replaces the {name} references in s by the variable values
+func expandVars(s string, vars map[string]string) string {
+
+	if len(vars) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+
+	oldnew := make([]string, 0, len(vars)*2)
+	for k, v := range vars {
+		oldnew = append(oldnew, "{"+k+"}", v)
+	}
+
+	return strings.NewReplacer(oldnew...).Replace(s)
+}
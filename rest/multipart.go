@@ -0,0 +1,226 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// Multipart is a multipart/form-data request body, made of fields & files.
+// It is streamed while the request is sent, so files are never fully
+// loaded in memory.
+//
+//	body := new(rest.Multipart).
+//		Field("name", "Hernan").
+//		File("avatar", "testdata/avatar.png")
+//
+//	resp := rest.Post("/users", body)
+//
+// The Content-Type of the request is set to multipart/form-data,
+// whatever the RequestBuilder ContentType.
+type Multipart struct {
+	parts    []MultipartPart
+	boundary string
+	once     sync.Once
+}
+
+// MultipartPart is a part of a Multipart body
+type MultipartPart struct {
+
+	// Form field name
+	Name string
+
+	// File name, empty for plain fields
+	FileName string
+
+	// Content-Type of the part.
+	// Default: none for plain fields, guessed from FileName for files,
+	// or application/octet-stream.
+	ContentType string
+
+	// Extra part headers
+	Header textproto.MIMEHeader
+
+	// Open returns the part content. If it returns an io.Closer, it is closed
+	// once read. Open is called again whenever the request is retried.
+	Open func() (io.Reader, error)
+}
+
+// Field adds a plain form field.
+func (m *Multipart) Field(name string, value string) *Multipart {
+	return m.Part(MultipartPart{
+		Name: name,
+		Open: func() (io.Reader, error) {
+			return strings.NewReader(value), nil
+		},
+	})
+}
+
+// File adds a file part read from path, named as the file.
+// The file is opened when the request is sent.
+func (m *Multipart) File(name string, path string) *Multipart {
+	return m.Part(MultipartPart{
+		Name:     name,
+		FileName: filepath.Base(path),
+		Open: func() (io.Reader, error) {
+			return os.Open(path)
+		},
+	})
+}
+
+// Reader adds a file part read from r.
+// As r can be read only once, requests with it can't be retried.
+func (m *Multipart) Reader(name string, fileName string, r io.Reader) *Multipart {
+
+	var mutex sync.Mutex
+	read := false
+
+	return m.Part(MultipartPart{
+		Name:     name,
+		FileName: fileName,
+		Open: func() (io.Reader, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if read {
+				return nil, fmt.Errorf("multipart: part %s can't be read twice", name)
+			}
+			read = true
+			return r, nil
+		},
+	})
+}
+
+// Bytes adds a file part with the content b.
+func (m *Multipart) Bytes(name string, fileName string, b []byte) *Multipart {
+	return m.Part(MultipartPart{
+		Name:     name,
+		FileName: fileName,
+		Open: func() (io.Reader, error) {
+			return bytes.NewReader(b), nil
+		},
+	})
+}
+
+// Part adds a part, letting you set its headers & content type.
+func (m *Multipart) Part(p MultipartPart) *Multipart {
+	m.parts = append(m.parts, p)
+	return m
+}
+
+// ContentType returns the multipart/form-data Content-Type, with its boundary.
+func (m *Multipart) ContentType() string {
+	return mime.FormatMediaType("multipart/form-data", map[string]string{"boundary": m.getBoundary()})
+}
+
+func (m *Multipart) getBoundary() string {
+	m.once.Do(func() {
+		m.boundary = multipart.NewWriter(nil).Boundary()
+	})
+	return m.boundary
+}
+
+// open returns a new reader of the whole body, which is written
+// in the background once it is first read.
+func (m *Multipart) open() (io.ReadCloser, error) {
+	return &multipartReader{multipart: m}, nil
+}
+
+func (m *Multipart) writeTo(pw *io.PipeWriter) {
+
+	w := multipart.NewWriter(pw)
+	w.SetBoundary(m.getBoundary())
+
+	for _, p := range m.parts {
+		if err := p.writeTo(w); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+	}
+
+	pw.CloseWithError(w.Close())
+}
+
+func (p *MultipartPart) writeTo(w *multipart.Writer) error {
+
+	header := make(textproto.MIMEHeader)
+	for k, v := range p.Header {
+		header[k] = v
+	}
+
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name))
+	if p.FileName != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(p.FileName))
+	}
+	header.Set("Content-Disposition", disposition)
+
+	contentType := p.ContentType
+	if contentType == "" && p.FileName != "" {
+		if contentType = mime.TypeByExtension(filepath.Ext(p.FileName)); contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	r, err := p.Open()
+	if err != nil {
+		return err
+	}
+
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	pw, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(pw, r)
+	return err
+}
+
+// multipartReader starts writing the body on its first Read, so nothing
+// is left running if the request is never sent.
+type multipartReader struct {
+	multipart *Multipart
+	once      sync.Once
+	pr        *io.PipeReader
+}
+
+func (r *multipartReader) Read(b []byte) (int, error) {
+
+	r.once.Do(func() {
+		var pw *io.PipeWriter
+		r.pr, pw = io.Pipe()
+		go r.multipart.writeTo(pw)
+	})
+
+	if r.pr == nil {
+		return 0, io.ErrClosedPipe
+	}
+
+	return r.pr.Read(b)
+}
+
+func (r *multipartReader) Close() error {
+
+	r.once.Do(func() {})
+
+	if r.pr == nil {
+		return nil
+	}
+
+	return r.pr.Close()
+}
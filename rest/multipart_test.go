@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+type receivedPart struct {
+	fileName string
+	header   textproto.MIMEHeader
+	content  string
+}
+
+func TestMultipart(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	body := new(Multipart).
+		Field("name", "Hernan").
+		File("user", "testdata/mockups/bodies/user.json").
+		Bytes("raw", "raw", []byte("raw")).
+		Reader("log", "app", strings.NewReader("line")).
+		Part(MultipartPart{
+			Name:        "meta",
+			ContentType: "application/json",
+			Header:      textproto.MIMEHeader{"X-Part": {"1"}},
+			Open: func() (io.Reader, error) {
+				return strings.NewReader(`{"v":1}`), nil
+			},
+		})
+
+	received := make(map[string]receivedPart)
+
+	ms.AddMockups(&Mock{
+		URL:        "http://mytest.com/upload",
+		HTTPMethod: http.MethodPost,
+		ReqHeaders: http.Header{"Content-Type": {body.ContentType()}},
+		ReqBodyMatcher: func(b []byte) bool {
+			r := multipart.NewReader(bytes.NewReader(b), body.getBoundary())
+			for {
+				p, err := r.NextPart()
+				if err != nil {
+					return err == io.EOF
+				}
+				content, _ := ioutil.ReadAll(p)
+				received[p.FormName()] = receivedPart{p.FileName(), p.Header, string(content)}
+			}
+		},
+		RespHTTPCode: http.StatusCreated,
+	})
+
+	builder := RequestBuilder{MockServer: ms}
+
+	if r := builder.Post("http://mytest.com/upload", body); r.Err != nil || r.StatusCode != http.StatusCreated {
+		t.Fatalf("Unexpected response %v %s", r.Err, r.String())
+	}
+
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		content     string
+	}{
+		{"name", "", "", "Hernan"},
+		{"user", "user.json", "application/json", `{"id":1,"name":"Max"}`},
+		{"raw", "raw", "application/octet-stream", "raw"},
+		{"log", "app", "application/octet-stream", "line"},
+		{"meta", "", "application/json", `{"v":1}`},
+	}
+
+	for _, test := range tests {
+		p := received[test.name]
+		if p.fileName != test.fileName || p.header.Get("Content-Type") != test.contentType || strings.TrimSpace(p.content) != test.content {
+			t.Fatalf("%s: unexpected part %+v", test.name, p)
+		}
+	}
+
+	if received["meta"].header.Get("X-Part") != "1" {
+		t.Fatal("Part headers should be sent")
+	}
+
+	// The reader part was already consumed
+	if r := builder.Post("http://mytest.com/upload", body); r.Err == nil {
+		t.Fatal("A reader part can't be sent twice")
+	}
+
+	missing := new(Multipart).File("user", "testdata/missing.json")
+	if r := builder.Post("http://mytest.com/upload", missing); r.Err == nil {
+		t.Fatal("Missing files should get an error")
+	}
+}
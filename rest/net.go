@@ -1,46 +1,52 @@
 package rest
 
 import (
-	"bytes"
-	"encoding/json"
-	"encoding/xml"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
-var readVerbs = [3]string{http.MethodGet, http.MethodHead, http.MethodOptions}
-var contentVerbs = [3]string{http.MethodPost, http.MethodPut, http.MethodPatch}
+var readVerbs = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+var contentVerbs = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+var cacheVerbs = []string{http.MethodGet, http.MethodHead}
 var defaultCheckRedirectFunc func(req *http.Request, via []*http.Request) error
 
 var maxAge = regexp.MustCompile(`(?:max-age|s-maxage)=(\d+)`)
 
-const httpDateFormat string = "Mon, 01 Jan 2019 12:00:00 GMT"
+// Date format from RFC-7231, Section 7.1.1.1 (always expressed in GMT)
+const httpDateFormat string = http.TimeFormat
 
-func (rb *RequestBuilder) doRequest(verb string, url string, body interface{}) (result *Response) {
+func (rb *RequestBuilder) doRequest(ctx context.Context, verb string, url string, body interface{}) (result *Response) {
 	var cacheURL string
 	var cacheResp *Response
 
 	result = new(Response)
 	url = rb.BaseURL + url
 
-	func(verb string, reqURL string, reqBody interface{}) {
+	ms := rb.getMockServer(ctx)
+	key := cacheKey(ms, verb, url)
+	cacheable := !rb.DisableCache && !rb.Stream && !rb.hasCredentials() && matchVerbs(verb, cacheVerbs)
 
-		//Marshal request to JSON or XML
-		body, err := rb.marshalReqBody(reqBody)
-		if err != nil {
-			result.Err = err
-			return
+	//If Cache enable && operation is read: Cache GET
+	if cacheable {
+		if cacheResp = resourceCache.get(key); cacheResp != nil && !cacheResp.revalidate {
+			return cacheResp.hit()
 		}
+	}
+
+	func(verb string, reqURL string, reqBody interface{}) {
+
+		var err error
 
 		// Change URL to point to Mockup server
-		reqURL, cacheURL, err = checkMockup(reqURL)
+		reqURL, cacheURL, err = checkMockup(ms, reqURL)
 		if err != nil {
 			result.Err = err
 			return
@@ -49,38 +55,63 @@ func (rb *RequestBuilder) doRequest(verb string, url string, body interface{}) (
 		//Get Client (client + transport)
 		client := rb.getClient()
 
-		request, err := http.NewRequest(verb, reqURL, bytes.NewBuffer(body))
+		request, err := http.NewRequestWithContext(ctx, verb, reqURL, nil)
 		if err != nil {
 			result.Err = err
 			return
 		}
 
-		// Set extra parameters
-		rb.setParams(request, cacheResp, cacheURL)
-
-		// Make the request
-		httpResp, err := client.Do(request)
+		//Marshal request to JSON or XML, or stream it.
+		//Opened last, so streamed bodies are always handed to the request.
+		body, err := rb.newRequestBody(reqBody)
 		if err != nil {
 			result.Err = err
 			return
 		}
 
-		// Read response
-		defer httpResp.Body.Close()
-		respBody, err := ioutil.ReadAll(httpResp.Body)
-		if err != nil {
+		if err = body.setTo(request); err != nil {
 			result.Err = err
 			return
 		}
 
+		// Set extra parameters
+		rb.setParams(request, cacheResp, cacheURL, ms != nil)
+
+		if body.contentType != "" {
+			request.Header.Set("Content-Type", body.contentType)
+		}
+
+		// Make the request through the middleware chain, retrying if needed
+		resp := rb.send(client, request)
+		if resp.Err != nil {
+			result = resp
+			return
+		}
+
+		httpResp := resp.Response
+
 		// If we get a 304, return response from cache
-		if httpResp.StatusCode == http.StatusNotModified {
-			result = cacheResp
+		if httpResp.StatusCode == http.StatusNotModified && cacheResp != nil {
+			result = resourceCache.refresh(key, cacheResp, &Response{Response: httpResp}).hit()
 			return
 		}
 
-		result.Response = httpResp
-		result.byteBody = respBody
+		result = resp
+
+		if rb.StatusErrors && !resp.IsSuccess() {
+			// Read streamed error bodies, so the HTTPError gets them
+			// and the connection is released
+			var readErr error
+			if resp.streamed {
+				readErr = resp.readStream()
+			}
+
+			result.Err = newHTTPError(resp, cacheURL)
+			if readErr != nil {
+				result.Err = errors.Join(result.Err, readErr)
+			}
+			return
+		}
 
 		ttl := setTTL(result)
 		lastModified := setLastModified(result)
@@ -89,6 +120,16 @@ func (rb *RequestBuilder) doRequest(verb string, url string, body interface{}) (
 		if !ttl && (lastModified || etag) {
 			result.revalidate = true
 		}
+
+		//If Cache enable: Cache SETNX, or SET if we are replacing a stale response
+		if cacheable && httpResp.StatusCode == http.StatusOK && (ttl || lastModified || etag) &&
+			request.Header.Get("Authorization") == "" && isShareable(httpResp) {
+			if cacheResp != nil {
+				resourceCache.set(key, result)
+			} else {
+				resourceCache.setNX(key, result)
+			}
+		}
 		return
 	}(verb, url, body)
 
@@ -96,21 +137,70 @@ func (rb *RequestBuilder) doRequest(verb string, url string, body interface{}) (
 
 }
 
-func checkMockup(reqURL string) (string, string, error) {
+// hasCredentials reports if the requests of rb carry an Authorization header
+func (rb *RequestBuilder) hasCredentials() bool {
+	return rb.BasicAuth != nil || rb.Headers.Get("Authorization") != ""
+}
 
-	cacheURL := reqURL
+// isShareable reports if a response may be stored in the shared cache:
+// not private, nor no-store, nor varying on request headers
+// (as the cache key has none of them).
+func isShareable(resp *http.Response) bool {
 
-	if mockUpEnv {
+	for _, directive := range strings.Split(strings.ToLower(resp.Header.Get("Cache-Control")), ",") {
+		switch strings.TrimSpace(directive) {
+		case "private", "no-store":
+			return false
+		}
+	}
 
-		rURL, err := url.Parse(reqURL)
-		if err != nil {
-			return reqURL, cacheURL, err
+	for _, v := range resp.Header.Values("Vary") {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" && !strings.EqualFold(h, "Accept-Encoding") {
+				return false
+			}
 		}
+	}
 
-		rURL.Scheme = mockServerURL.Scheme
-		rURL.Host = mockServerURL.Host
+	return true
+}
 
-		return rURL.String(), cacheURL, nil
+// cacheKey builds the resourceCache key for a given verb and (original) url,
+// so GET and HEAD responses for the same resource don't overwrite each other,
+// nor mocked responses leak out of their mock server.
+func cacheKey(ms *MockServer, verb string, reqURL string) string {
+	if ms != nil {
+		return ms.URL() + " " + verb + " " + reqURL
+	}
+	return verb + " " + reqURL
+}
+
+// getMockServer returns the mock server requests should be sent to, if any:
+// the RequestBuilder one, the context one, or the global mockup server if started.
+func (rb *RequestBuilder) getMockServer(ctx context.Context) *MockServer {
+
+	if rb.MockServer != nil {
+		return rb.MockServer
+	}
+
+	if ms, ok := ctx.Value(mockServerKey{}).(*MockServer); ok && ms != nil {
+		return ms
+	}
+
+	if mockUpEnv {
+		return mockServer
+	}
+
+	return nil
+}
+
+func checkMockup(ms *MockServer, reqURL string) (string, string, error) {
+
+	cacheURL := reqURL
+
+	if ms != nil {
+		rURL, err := ms.route(reqURL)
+		return rURL, cacheURL, err
 	}
 
 	return reqURL, cacheURL, nil
@@ -119,23 +209,36 @@ func checkMockup(reqURL string) (string, string, error) {
 func (rb *RequestBuilder) marshalReqBody(body interface{}) (b []byte, err error) {
 
 	if body != nil {
-		switch rb.ContentType {
-		case JSON:
-			b, err = json.Marshal(body)
-		case XML:
-			b, err = xml.Marshal(body)
-		case BYTES:
+		mediaType := rb.mediaType()
+
+		if mediaType == "" {
 			var ok bool
 			b, ok = body.([]byte)
 			if !ok {
 				err = fmt.Errorf("bytes: body is %T(%v) not a byte slice", body, body)
 			}
+			return
+		}
+
+		codec := CodecFor(mediaType)
+		if codec == nil {
+			return nil, fmt.Errorf("no codec registered for media type %s", mediaType)
 		}
+
+		b, err = codec.Marshal(body)
 	}
 
 	return
 }
 
+// mediaType returns the MediaType, or the one of the ContentType
+func (rb *RequestBuilder) mediaType() string {
+	if rb.MediaType != "" {
+		return rb.MediaType
+	}
+	return rb.ContentType.mediaType()
+}
+
 func (rb *RequestBuilder) getClient() *http.Client {
 
 	// This will be executed only once
@@ -193,7 +296,7 @@ func (rb *RequestBuilder) getClient() *http.Client {
 
 	if !rb.FollowRedirect {
 		rb.Client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return errors.New("Avoided redirect attempt")
+			return ErrRedirectBlocked
 		}
 	} else {
 		rb.Client.CheckRedirect = defaultCheckRedirectFunc
@@ -226,7 +329,19 @@ func (rb *RequestBuilder) getConnectionTimeout() time.Duration {
 	}
 }
 
-func (rb *RequestBuilder) setParams(req *http.Request, cacheResp *Response, cacheURL string) {
+func (rb *RequestBuilder) getMaxResponseBytes() int64 {
+
+	switch {
+	case rb.MaxResponseBytes > 0:
+		return rb.MaxResponseBytes
+	case rb.MaxResponseBytes < 0:
+		return 0
+	default:
+		return DefaultMaxResponseBytes
+	}
+}
+
+func (rb *RequestBuilder) setParams(req *http.Request, cacheResp *Response, cacheURL string, mocked bool) {
 
 	//Custom Headers
 	if rb.Headers != nil {
@@ -242,7 +357,7 @@ func (rb *RequestBuilder) setParams(req *http.Request, cacheResp *Response, cach
 	req.Header.Set("Cache-Control", "no-cache")
 
 	//If mock
-	if mockUpEnv {
+	if mocked {
 		req.Header.Set("X-Original-URL", cacheURL)
 	}
 
@@ -260,20 +375,14 @@ func (rb *RequestBuilder) setParams(req *http.Request, cacheResp *Response, cach
 	}())
 
 	//Encoding
-	var cType string
-
-	switch rb.ContentType {
-	case JSON:
-		cType = "json"
-	case XML:
-		cType = "xml"
-	}
-
-	if cType != "" {
-		req.Header.Set("Accept", "application/"+cType)
+	if cType := rb.mediaType(); cType != "" {
+		// No form Accept header: form encoded responses are not asked for by default
+		if cType != FormCodec.MediaType() {
+			req.Header.Set("Accept", cType)
+		}
 
 		if matchVerbs(req.Method, contentVerbs) {
-			req.Header.Set("Content-Type", "application/"+cType)
+			req.Header.Set("Content-Type", cType)
 		}
 	}
 
@@ -282,13 +391,13 @@ func (rb *RequestBuilder) setParams(req *http.Request, cacheResp *Response, cach
 		case cacheResp.etag != "":
 			req.Header.Set("If-None-Match", cacheResp.etag)
 		case cacheResp.lastModified != nil:
-			req.Header.Set("If-Modified-Since", cacheResp.lastModified.Format(httpDateFormat))
+			req.Header.Set("If-Modified-Since", cacheResp.lastModified.UTC().Format(httpDateFormat))
 		}
 	}
 
 }
 
-func matchVerbs(s string, sarray [3]string) bool {
+func matchVerbs(s string, sarray []string) bool {
 	for i := 0; i < len(sarray); i++ {
 		if sarray[i] == s {
 			return true
@@ -356,3 +465,16 @@ func setETag(resp *Response) bool {
 
 	return false
 }
+
+// originalHost returns the host a request was meant for,
+// which differs from req.URL.Host when the request was sent to a MockServer.
+func originalHost(req *http.Request) string {
+
+	if originalURL := req.Header.Get("X-Original-URL"); originalURL != "" {
+		if u, err := url.Parse(originalURL); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+
+	return req.URL.Host
+}
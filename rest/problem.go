@@ -0,0 +1,146 @@
+package rest
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ProblemMediaType is the media type of RFC 7807 problem details
+const ProblemMediaType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body, used by APIs to describe errors.
+//
+// It is available from the Response, and from the *HTTPError of a
+// RequestBuilder with StatusErrors, so it can be taken from the error:
+//
+//	var problem *rest.Problem
+//	if errors.As(resp.Err, &problem) {
+//		log.Println(problem.Title, problem.Detail)
+//	}
+type Problem struct {
+
+	// URI identifying the problem type. Default: about:blank
+	Type string
+
+	// Short, human-readable summary of the problem type
+	Title string
+
+	// HTTP status code. Default: the response status code
+	Status int
+
+	// Human-readable explanation of this occurrence of the problem
+	Detail string
+
+	// URI identifying this occurrence of the problem
+	Instance string
+
+	// Any other member of the problem
+	Extensions map[string]interface{}
+}
+
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+func (p *Problem) Error() string {
+
+	msg := p.Title
+	if msg == "" {
+		msg = p.Type
+	}
+
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+
+	return msg
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *Problem) UnmarshalJSON(b []byte) error {
+
+	var members struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance"`
+	}
+
+	if err := json.Unmarshal(b, &members); err != nil {
+		return err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+
+	for _, k := range problemMembers {
+		delete(all, k)
+	}
+
+	*p = Problem{
+		Type:     members.Type,
+		Title:    members.Title,
+		Status:   members.Status,
+		Detail:   members.Detail,
+		Instance: members.Instance,
+	}
+
+	if len(all) > 0 {
+		p.Extensions = all
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, flattening the Extensions
+func (p Problem) MarshalJSON() ([]byte, error) {
+
+	all := make(map[string]interface{}, len(p.Extensions)+len(problemMembers))
+	for k, v := range p.Extensions {
+		all[k] = v
+	}
+
+	for k, v := range map[string]interface{}{
+		"type":     p.Type,
+		"title":    p.Title,
+		"status":   p.Status,
+		"detail":   p.Detail,
+		"instance": p.Instance,
+	} {
+		if v != "" && v != 0 {
+			all[k] = v
+		}
+	}
+
+	return json.Marshal(all)
+}
+
+// Problem returns the problem details of an application/problem+json
+// response, or nil.
+func (r *Response) Problem() *Problem {
+
+	if r.Response == nil || r.streamed {
+		return nil
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]))
+	if mediaType != ProblemMediaType {
+		return nil
+	}
+
+	p := new(Problem)
+	if err := json.Unmarshal(r.byteBody, p); err != nil {
+		return nil
+	}
+
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+
+	if p.Status == 0 {
+		p.Status = r.StatusCode
+	}
+
+	return p
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestProblem(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/accounts/12345/transfer",
+		HTTPMethod:   http.MethodPost,
+		RespHTTPCode: http.StatusForbidden,
+		RespHeaders:  http.Header{"Content-Type": {"application/problem+json; charset=utf-8"}},
+		RespBody: `{
+			"type": "https://example.com/probs/out-of-credit",
+			"title": "You do not have enough credit.",
+			"detail": "Your current balance is 30, but that costs 50.",
+			"instance": "/account/12345/msgs/abc",
+			"balance": 30
+		}`,
+	}, &Mock{
+		URL:          "http://mytest.com/accounts/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+		RespHeaders:  http.Header{"Content-Type": {"application/json"}},
+		RespBody:     `{"title": "Not a problem"}`,
+	})
+
+	builder := RequestBuilder{MockServer: ms, StatusErrors: true}
+
+	r := builder.Post("http://mytest.com/accounts/12345/transfer", map[string]int{"amount": 50})
+
+	p := r.Problem()
+	if p == nil {
+		t.Fatal("Should have a problem")
+	}
+
+	if p.Type != "https://example.com/probs/out-of-credit" || p.Title != "You do not have enough credit." ||
+		p.Status != http.StatusForbidden || p.Instance != "/account/12345/msgs/abc" || p.Extensions["balance"] != 30.0 {
+		t.Fatalf("Unexpected problem %+v", p)
+	}
+
+	var problem *Problem
+	if !errors.As(r.Err, &problem) || problem.Detail != p.Detail {
+		t.Fatalf("Error should wrap the problem, got %v", r.Err)
+	}
+
+	expected := "POST http://mytest.com/accounts/12345/transfer: 403 Forbidden: " +
+		"You do not have enough credit.: Your current balance is 30, but that costs 50."
+	if r.Err.Error() != expected {
+		t.Fatalf("Unexpected message %s", r.Err.Error())
+	}
+
+	r = builder.Get("http://mytest.com/accounts/1")
+	if r.Problem() != nil || errors.As(r.Err, &problem) {
+		t.Fatal("Plain JSON responses are not problems")
+	}
+}
+
+func TestProblemJSON(t *testing.T) {
+
+	p := Problem{Title: "Out of credit", Status: 403, Extensions: map[string]interface{}{"balance": 30}}
+
+	b, err := json.Marshal(p)
+	if err != nil || string(b) != `{"balance":30,"status":403,"title":"Out of credit"}` {
+		t.Fatalf("Unexpected JSON %s, error: %v", b, err)
+	}
+
+	var decoded Problem
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded.Title != p.Title || decoded.Extensions["balance"] != 30.0 {
+		t.Fatalf("Unexpected problem %+v, error: %v", decoded, err)
+	}
+}
@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitError is the Response error when a RateLimiter set to FailFast
+// has no tokens left.
+type RateLimitError struct {
+	Host string
+
+	// Time to wait until the request would be allowed
+	RetryIn time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Host == "" {
+		return fmt.Sprintf("rate limit exceeded, retry in %v", e.RetryIn)
+	}
+	return fmt.Sprintf("rate limit exceeded for host %s, retry in %v", e.Host, e.RetryIn)
+}
+
+// RateLimiter is a token-bucket limiter for the requests of a RequestBuilder.
+//
+// By default requests wait for a token, honoring the request context.
+// With FailFast, they fail right away with a *RateLimitError.
+//
+// The limiter comes before the RequestBuilder CircuitBreaker, so requests
+// rejected by an open circuit still use up a token.
+//
+// A RateLimiter is thread-safe and may be shared by many RequestBuilders.
+type RateLimiter struct {
+
+	// Tokens added to the bucket per second.
+	// Zero means no limit, unless Adaptive is set.
+	RequestsPerSecond float64
+
+	// Size of the bucket. Default: RequestsPerSecond, and at least 1.
+	Burst int
+
+	// Keep a bucket per host instead of one for the RequestBuilder.
+	PerHost bool
+
+	// Fail instead of waiting for a token.
+	FailFast bool
+
+	// Adapt the bucket to the X-RateLimit-Remaining & X-RateLimit-Reset
+	// response headers. Reset may be either seconds or a unix timestamp.
+	Adaptive bool
+
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func (rl *RateLimiter) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) *Response {
+
+		key := rl.key(req)
+
+		if d := rl.reserve(key, time.Now()); d > 0 {
+
+			if rl.FailFast {
+				rl.cancel(key)
+				return &Response{Err: &RateLimitError{Host: key, RetryIn: d}}
+			}
+
+			if !wait(req.Context(), d) {
+				rl.cancel(key)
+				return &Response{Err: classifyError(req.Context().Err())}
+			}
+		}
+
+		resp := next(req)
+
+		if rl.Adaptive && resp.Response != nil {
+			rl.adapt(key, resp.Header, time.Now())
+		}
+
+		return resp
+	}
+}
+
+func (rl *RateLimiter) key(req *http.Request) string {
+	if rl.PerHost {
+		return originalHost(req)
+	}
+	return ""
+}
+
+// reserve takes a token, returning how long to wait for it
+func (rl *RateLimiter) reserve(key string, now time.Time) time.Duration {
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	b := rl.bucket(key, now)
+
+	var d time.Duration
+	if now.Before(b.blockedUntil) {
+		d = b.blockedUntil.Sub(now)
+	}
+
+	if rl.RequestsPerSecond > 0 {
+		b.tokens--
+
+		if b.tokens < 0 {
+			if td := time.Duration(-b.tokens / rl.RequestsPerSecond * float64(time.Second)); td > d {
+				d = td
+			}
+		}
+	}
+
+	return d
+}
+
+// cancel gives back a token taken by reserve
+func (rl *RateLimiter) cancel(key string) {
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	if rl.RequestsPerSecond > 0 {
+		b := rl.bucket(key, time.Now())
+		b.tokens = math.Min(b.tokens+1, rl.burst())
+	}
+}
+
+func (rl *RateLimiter) adapt(key string, header http.Header, now time.Time) {
+
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	b := rl.bucket(key, now)
+
+	if remaining > 0 {
+		b.tokens = math.Min(b.tokens, float64(remaining))
+		return
+	}
+
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < 0 {
+		return
+	}
+
+	// Big numbers are unix timestamps, small ones seconds to wait
+	resetAt := now.Add(time.Duration(reset) * time.Second)
+	if reset > 1000000000 {
+		resetAt = time.Unix(reset, 0)
+	}
+
+	if resetAt.After(b.blockedUntil) {
+		b.blockedUntil = resetAt
+	}
+}
+
+// bucket returns the refilled bucket for key. Lock must be held by the caller.
+func (rl *RateLimiter) bucket(key string, now time.Time) *tokenBucket {
+
+	if rl.buckets == nil {
+		rl.buckets = make(map[string]*tokenBucket)
+	}
+
+	b := rl.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: rl.burst(), last: now}
+		rl.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.tokens+elapsed.Seconds()*rl.RequestsPerSecond, rl.burst())
+		b.last = now
+	}
+
+	return b
+}
+
+func (rl *RateLimiter) burst() float64 {
+	if rl.Burst > 0 {
+		return float64(rl.Burst)
+	}
+	return math.Max(1, math.Ceil(rl.RequestsPerSecond))
+}
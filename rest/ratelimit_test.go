@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWaits(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RateLimiter: &RateLimiter{RequestsPerSecond: 50, Burst: 1},
+	}
+
+	start := time.Now()
+
+	for i := 0; i < 4; i++ {
+		if r := builder.Get("/user"); r.StatusCode != http.StatusOK {
+			t.Fatal("Status != OK (200)")
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Fatalf("Requests were not rate limited, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RateLimiter: &RateLimiter{RequestsPerSecond: 1, FailFast: true},
+	}
+
+	if r := builder.Get("/user"); r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	r := builder.Get("/user")
+
+	var rlErr *RateLimitError
+	if !errors.As(r.Err, &rlErr) {
+		t.Fatalf("Expected RateLimitError, got %v", r.Err)
+	}
+}
+
+func TestRateLimiterContext(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RateLimiter: &RateLimiter{RequestsPerSecond: 1},
+	}
+
+	builder.Get("/user")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	r := builder.GetCtx(ctx, "/user")
+	if !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", r.Err)
+	}
+}
+
+func TestRateLimiterPerHost(t *testing.T) {
+
+	other := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+	defer other.Close()
+
+	builder := RequestBuilder{
+		RateLimiter: &RateLimiter{RequestsPerSecond: 1, PerHost: true, FailFast: true},
+	}
+
+	if r := builder.Get(server.URL + "/user"); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := builder.Get(other.URL + "/user"); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+}
+
+func TestRateLimiterAdaptive(t *testing.T) {
+
+	limited := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.Header().Set("X-RateLimit-Remaining", "0")
+		writer.Header().Set("X-RateLimit-Reset", "60")
+	}))
+	defer limited.Close()
+
+	builder := RequestBuilder{
+		BaseURL:     limited.URL,
+		RateLimiter: &RateLimiter{Adaptive: true, FailFast: true},
+	}
+
+	if r := builder.Get("/user"); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	r := builder.Get("/user")
+
+	var rlErr *RateLimitError
+	if !errors.As(r.Err, &rlErr) || rlErr.RetryIn <= 59*time.Second {
+		t.Fatalf("Expected RateLimitError until reset, got %v", r.Err)
+	}
+}
+
+func TestRateLimiterPerMockedHost(t *testing.T) {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	ms.AddMockups(
+		&Mock{URL: "http://one.com/user", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+		&Mock{URL: "http://other.com/user", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+	)
+
+	builder := RequestBuilder{
+		MockServer:  ms,
+		RateLimiter: &RateLimiter{RequestsPerSecond: 1, PerHost: true, FailFast: true},
+	}
+
+	if r := builder.Get("http://one.com/user"); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := builder.Get("http://other.com/user"); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	var rlErr *RateLimitError
+	if r := builder.Get("http://one.com/user"); !errors.As(r.Err, &rlErr) || rlErr.Host != "one.com" {
+		t.Fatalf("Expected RateLimitError for one.com, got %v", r.Err)
+	}
+}
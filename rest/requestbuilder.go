@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -21,12 +22,17 @@ var DefaultTimeout = 500 * time.Millisecond
 // DefaultConnectTimeout ...
 var DefaultConnectTimeout = 1500 * time.Millisecond
 
+// DefaultMaxResponseBytes is the default MaxResponseBytes for all clients.
+// Zero means no limit.
+var DefaultMaxResponseBytes int64
+
 // DefaultMaxIdleConnsPerHost is the default maxium idle connections to have
 // per Host for all clients, that use *any* RequestBuilder that don't set
 // a CustomPool
 var DefaultMaxIdleConnsPerHost = 2
 
-// ContentType represents the HTTP Vebs body's type
+// ContentType represents the HTTP Vebs body's type.
+// For any other media type, see RequestBuilder.MediaType.
 type ContentType int
 
 const (
@@ -36,8 +42,24 @@ const (
 	XML
 	// BYTES represents a plain content type
 	BYTES
+	// FORM represents an application/x-www-form-urlencoded content type, see FormCodec
+	FORM
 )
 
+// mediaType returns the media type of c, empty for BYTES
+func (c ContentType) mediaType() string {
+	switch c {
+	case JSON:
+		return JSONCodec.MediaType()
+	case XML:
+		return XMLCodec.MediaType()
+	case FORM:
+		return FormCodec.MediaType()
+	default:
+		return ""
+	}
+}
+
 // RequestBuilder is the baseline to create requests
 // There is a DefaultBuilder that you may use for simple requests
 // RequestBuilder is thread-safe and should be stored for later usage.
@@ -58,9 +80,29 @@ type RequestBuilder struct {
 	// ContentType
 	ContentType ContentType
 
-	// Disable internal caching of response
+	// MediaType of the request body, like application/vnd.api+json.
+	// It must have a registered Codec, see RegisterCodec.
+	// When set, ContentType is ignored.
+	MediaType string
+
+	// Disable internal caching of response.
+	// The cache is shared by every RequestBuilder and keyed by method & URL only,
+	// so requests with credentials (BasicAuth or an Authorization header) and
+	// responses marked private, no-store, or with a Vary header, are never cached.
 	DisableCache bool
 
+	// Stream responses instead of reading them, see Response.Stream.
+	// Streamed responses are never cached, and must be closed.
+	Stream bool
+
+	// Report non 2xx responses as a *HTTPError in Response.Err
+	StatusErrors bool
+
+	// Maximum size of response bodies. Bigger ones fail with a
+	// *ResponseTooLargeError. A negative value means no limit.
+	// Default: DefaultMaxResponseBytes
+	MaxResponseBytes int64
+
 	// Disable timeout.
 	DisableTimeout bool
 
@@ -79,6 +121,22 @@ type RequestBuilder struct {
 	// Public for custom fine tuning
 	Client *http.Client
 
+	// Retry failed requests. Default: no retries
+	RetryPolicy *RetryPolicy
+
+	// Short-circuit requests to failing hosts. Default: disabled
+	CircuitBreaker *CircuitBreaker
+
+	// Limit the rate of requests. Default: disabled
+	RateLimiter *RateLimiter
+
+	// Send every request to this mock server, instead of the global one
+	MockServer *MockServer
+
+	// Middlewares wrapping every request made by this RequestBuilder.
+	// They run after the global ones, see AddMiddlewares.
+	Middlewares []Middleware
+
 	clientMtxOnce sync.Once
 }
 
@@ -99,76 +157,153 @@ type BasicAuth struct {
 
 // Get ...
 func (rb *RequestBuilder) Get(url string) *Response {
-	return rb.doRequest(http.MethodGet, url, nil)
+	return rb.GetCtx(context.Background(), url)
+}
+
+// GetCtx is the context-aware option for Get.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) GetCtx(ctx context.Context, url string) *Response {
+	return rb.doRequest(ctx, http.MethodGet, url, nil)
 }
 
 // Post ...
 func (rb *RequestBuilder) Post(url string, body interface{}) *Response {
-	return rb.doRequest(http.MethodPost, url, body)
+	return rb.PostCtx(context.Background(), url, body)
+}
+
+// PostCtx is the context-aware option for Post.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) PostCtx(ctx context.Context, url string, body interface{}) *Response {
+	return rb.doRequest(ctx, http.MethodPost, url, body)
 }
 
 // Put ...
 func (rb *RequestBuilder) Put(url string, body interface{}) *Response {
-	return rb.doRequest(http.MethodPut, url, body)
+	return rb.PutCtx(context.Background(), url, body)
+}
+
+// PutCtx is the context-aware option for Put.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) PutCtx(ctx context.Context, url string, body interface{}) *Response {
+	return rb.doRequest(ctx, http.MethodPut, url, body)
 }
 
 // Delete ...
 func (rb *RequestBuilder) Delete(url string) *Response {
-	return rb.doRequest(http.MethodDelete, url, nil)
+	return rb.DeleteCtx(context.Background(), url)
+}
+
+// DeleteCtx is the context-aware option for Delete.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) DeleteCtx(ctx context.Context, url string) *Response {
+	return rb.doRequest(ctx, http.MethodDelete, url, nil)
 }
 
 // Patch ...
 func (rb *RequestBuilder) Patch(url string, body interface{}) *Response {
-	return rb.doRequest(http.MethodPatch, url, body)
+	return rb.PatchCtx(context.Background(), url, body)
+}
+
+// PatchCtx is the context-aware option for Patch.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) PatchCtx(ctx context.Context, url string, body interface{}) *Response {
+	return rb.doRequest(ctx, http.MethodPatch, url, body)
 }
 
 // Head ...
 func (rb *RequestBuilder) Head(url string) *Response {
-	return rb.doRequest(http.MethodHead, url, nil)
+	return rb.HeadCtx(context.Background(), url)
+}
+
+// HeadCtx is the context-aware option for Head.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) HeadCtx(ctx context.Context, url string) *Response {
+	return rb.doRequest(ctx, http.MethodHead, url, nil)
 }
 
 // Options ...
 func (rb *RequestBuilder) Options(url string) *Response {
-	return rb.doRequest(http.MethodOptions, url, nil)
+	return rb.OptionsCtx(context.Background(), url)
+}
+
+// OptionsCtx is the context-aware option for Options.
+// The request will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) OptionsCtx(ctx context.Context, url string) *Response {
+	return rb.doRequest(ctx, http.MethodOptions, url, nil)
 }
 
 // AsyncGet ...
 func (rb *RequestBuilder) AsyncGet(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Get(url), f)
+	rb.AsyncGetCtx(context.Background(), url, f)
+}
+
+// AsyncGetCtx is the context-aware option for AsyncGet.
+func (rb *RequestBuilder) AsyncGetCtx(ctx context.Context, url string, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodGet, url, nil, f)
 }
 
 // AsyncPost ...
 func (rb *RequestBuilder) AsyncPost(url string, body interface{}, f func(*Response)) {
-	go doAsyncRequest(rb.Post(url, body), f)
+	rb.AsyncPostCtx(context.Background(), url, body, f)
+}
+
+// AsyncPostCtx is the context-aware option for AsyncPost.
+func (rb *RequestBuilder) AsyncPostCtx(ctx context.Context, url string, body interface{}, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodPost, url, body, f)
 }
 
 // AsyncPut ...
 func (rb *RequestBuilder) AsyncPut(url string, body interface{}, f func(*Response)) {
-	go doAsyncRequest(rb.Put(url, body), f)
+	rb.AsyncPutCtx(context.Background(), url, body, f)
+}
+
+// AsyncPutCtx is the context-aware option for AsyncPut.
+func (rb *RequestBuilder) AsyncPutCtx(ctx context.Context, url string, body interface{}, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodPut, url, body, f)
 }
 
 // AsyncPatch ...
 func (rb *RequestBuilder) AsyncPatch(url string, body interface{}, f func(*Response)) {
-	go doAsyncRequest(rb.Patch(url, body), f)
+	rb.AsyncPatchCtx(context.Background(), url, body, f)
+}
+
+// AsyncPatchCtx is the context-aware option for AsyncPatch.
+func (rb *RequestBuilder) AsyncPatchCtx(ctx context.Context, url string, body interface{}, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodPatch, url, body, f)
 }
 
 // AsyncDelete ...
 func (rb *RequestBuilder) AsyncDelete(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Delete(url), f)
+	rb.AsyncDeleteCtx(context.Background(), url, f)
+}
+
+// AsyncDeleteCtx is the context-aware option for AsyncDelete.
+func (rb *RequestBuilder) AsyncDeleteCtx(ctx context.Context, url string, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodDelete, url, nil, f)
 }
 
 // AsyncHead ...
 func (rb *RequestBuilder) AsyncHead(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Head(url), f)
+	rb.AsyncHeadCtx(context.Background(), url, f)
+}
+
+// AsyncHeadCtx is the context-aware option for AsyncHead.
+func (rb *RequestBuilder) AsyncHeadCtx(ctx context.Context, url string, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodHead, url, nil, f)
 }
 
 // AsyncOptions ...
 func (rb *RequestBuilder) AsyncOptions(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Options(url), f)
+	rb.AsyncOptionsCtx(context.Background(), url, f)
+}
+
+// AsyncOptionsCtx is the context-aware option for AsyncOptions.
+func (rb *RequestBuilder) AsyncOptionsCtx(ctx context.Context, url string, f func(*Response)) {
+	go rb.doAsyncRequest(ctx, http.MethodOptions, url, nil, f)
 }
 
-func doAsyncRequest(r *Response, f func(*Response)) {
-	f(r)
+func (rb *RequestBuilder) doAsyncRequest(ctx context.Context, verb string, url string, body interface{}, f func(*Response)) {
+	f(rb.doRequest(ctx, verb, url, body))
 }
 
 // ForkJoin let you *fork* requests, and *wait* until all of them have return.
@@ -184,9 +319,16 @@ func doAsyncRequest(r *Response, f func(*Response)) {
 //	fmt.Println(futureB.Response())
 //
 func (rb *RequestBuilder) ForkJoin(f func(c *Concurrent)) {
+	rb.ForkJoinCtx(context.Background(), f)
+}
+
+// ForkJoinCtx is the context-aware option for ForkJoin.
+// Every request forked by the Concurrent will be cancelled whenever ctx is done.
+func (rb *RequestBuilder) ForkJoinCtx(ctx context.Context, f func(c *Concurrent)) {
 
 	c := new(Concurrent)
 	c.reqBuilder = rb
+	c.ctx = ctx
 
 	f(c)
 
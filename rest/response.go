@@ -1,13 +1,14 @@
 package rest
 
 import (
+	"bytes"
 	"container/list"
-	"encoding/json"
-	"encoding/xml"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
-	"strings"
+	"os"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -25,6 +26,8 @@ type Response struct {
 	etag            string
 	revalidate      bool
 	cacheHit        atomic.Value
+	attempts        int
+	streamed        bool
 }
 
 func (r *Response) size() int64 {
@@ -54,28 +57,113 @@ func (r *Response) Bytes() []byte {
 	return r.byteBody
 }
 
-// FillUp set the *fill* parameter with the corresponding JSON or XML response.
+// Stream returns the response body as a reader.
+//
+// For responses of a streaming RequestBuilder, it is the live body, which
+// can be read only once and must be closed. String, Bytes & FillUp know
+// nothing about it.
+//
+//	rb := rest.RequestBuilder{Stream: true}
+//	resp := rb.Get("/exports/1")
+//	defer resp.Close()
+//	_, err := io.Copy(w, resp.Stream())
+func (r *Response) Stream() io.ReadCloser {
+	if r.streamed && r.Response != nil {
+		return r.Response.Body
+	}
+	return ioutil.NopCloser(bytes.NewReader(r.byteBody))
+}
+
+// readStream reads and closes the live body of a streamed response,
+// which then behaves as a read one.
+func (r *Response) readStream() error {
+
+	b, err := ioutil.ReadAll(r.Response.Body)
+	r.Response.Body.Close()
+
+	r.byteBody = b
+	r.streamed = false
+
+	if err != nil {
+		return classifyError(err)
+	}
+	return nil
+}
+
+// Close closes the live body of a streamed response. It does nothing for
+// responses already read.
+func (r *Response) Close() error {
+	if r.streamed && r.Response != nil {
+		return r.Response.Body.Close()
+	}
+	return nil
+}
+
+// WriteTo writes the response body to w, and closes it.
+func (r *Response) WriteTo(w io.Writer) (int64, error) {
+
+	body := r.Stream()
+	defer body.Close()
+
+	return io.Copy(w, body)
+}
+
+// SaveTo writes the response body to the file at path, creating or
+// truncating it, and closes the body.
+func (r *Response) SaveTo(path string) (int64, error) {
+
+	if r.Err != nil {
+		r.Close()
+		return 0, r.Err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		r.Close()
+		return 0, err
+	}
+
+	n, err := r.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return n, err
+}
+
+// FillUp set the *fill* parameter with the response body, decoded by the
+// Codec registered for its Content-Type, like JSON or XML.
 // fill could be `struct`or `map[string]interface{}`
 func (r *Response) FillUp(fill interface{}) error {
 
-	cTypeJSON := "application/json"
-	cTypeXML := "application/xml"
+	cType := r.Header.Get("Content-Type")
+
+	codec := CodecFor(cType)
+	if codec == nil {
+		codec = CodecFor(http.DetectContentType(r.byteBody))
+	}
 
-	cType := strings.ToLower(r.Header.Get("Content-Type"))
+	if codec == nil {
+		return fmt.Errorf("Response format %q has no registered codec", cType)
+	}
+
+	return codec.Unmarshal(r.byteBody, fill)
+}
 
-	for i := 0; i < 2; i++ {
+// hit returns a copy of a cached response, marked as a cache hit,
+// leaving the shared cache entry untouched.
+func (r *Response) hit() *Response {
 
-		switch {
-		case strings.Contains(cType, cTypeJSON):
-			return json.Unmarshal(r.byteBody, fill)
-		case strings.Contains(cType, cTypeXML):
-			return xml.Unmarshal(r.byteBody, fill)
-		case i == 0:
-			cType = http.DetectContentType(r.byteBody)
-		}
+	hit := &Response{
+		Response:     r.Response,
+		byteBody:     r.byteBody,
+		ttl:          r.ttl,
+		lastModified: r.lastModified,
+		etag:         r.etag,
 	}
+	hit.cacheHit.Store(true)
 
-	return fmt.Errorf("Response format neither JSON nor XML")
+	return hit
 }
 
 // CacheHit shows if a response was get from the cache.
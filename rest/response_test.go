@@ -1,15 +1,19 @@
 package rest
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
 func TestResponseBytesAndString(t *testing.T) {
-	tmux := http.NewServeMux()
-	server := httptest.NewServer(tmux)
 
 	resp := Get(server.URL + "/user")
 
@@ -51,7 +55,7 @@ func TestGetFillUpJSON(t *testing.T) {
 		t.Fatal("Json fill up failed. Error: " + err.Error())
 	}
 
-	for _, v := range users {
+	for _, v := range u {
 		if v.Name == "Hernan" {
 			return
 		}
@@ -62,7 +66,7 @@ func TestGetFillUpJSON(t *testing.T) {
 
 func TestGetFillUpXML(t *testing.T) {
 
-	var u []User
+	var u Users
 
 	var rbXML = RequestBuilder{
 		BaseURL:     server.URL,
@@ -80,7 +84,7 @@ func TestGetFillUpXML(t *testing.T) {
 		t.Fatal("Json fill up failed. Error: " + err.Error())
 	}
 
-	for _, v := range users {
+	for _, v := range u.Users {
 		if v.Name == "Hernan" {
 			return
 		}
@@ -88,3 +92,69 @@ func TestGetFillUpXML(t *testing.T) {
 
 	t.Fatal("Couldn't found Hernan")
 }
+
+func TestResponseStream(t *testing.T) {
+
+	release := make(chan struct{})
+	var calls int32
+
+	streamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+
+		fmt.Fprintln(w, "first")
+		w.(http.Flusher).Flush()
+
+		<-release
+		fmt.Fprintln(w, "second")
+	}))
+	defer streamServer.Close()
+
+	builder := RequestBuilder{Stream: true}
+
+	resp := builder.Get(streamServer.URL)
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	// The first line arrives while the server is still writing
+	lines := bufio.NewReader(resp.Stream())
+	if line, err := lines.ReadString('\n'); err != nil || line != "first\n" {
+		t.Fatalf("Unexpected line %q, error: %v", line, err)
+	}
+
+	close(release)
+
+	if line, err := lines.ReadString('\n'); err != nil || line != "second\n" {
+		t.Fatalf("Unexpected line %q, error: %v", line, err)
+	}
+
+	if err := resp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Streamed responses are not cached
+	path := filepath.Join(t.TempDir(), "stream.txt")
+
+	if n, err := builder.Get(streamServer.URL).SaveTo(path); err != nil || n != int64(len("first\nsecond\n")) {
+		t.Fatalf("Unexpected size %d, error: %v", n, err)
+	}
+
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "first\nsecond\n" {
+		t.Fatalf("Unexpected file %q, error: %v", b, err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("Expected 2 calls, got %d", calls)
+	}
+}
+
+func TestResponseWriteTo(t *testing.T) {
+
+	resp := Get(server.URL + "/user")
+
+	var buf bytes.Buffer
+	if _, err := resp.WriteTo(&buf); err != nil || buf.String() != resp.String() {
+		t.Fatalf("Unexpected body %q, error: %v", buf.String(), err)
+	}
+}
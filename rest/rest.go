@@ -1,6 +1,40 @@
 package rest
 
-var defaultBuilder = RequestBuilder{}
+import (
+	"context"
+	"sync"
+)
+
+var defaultBuilder = new(RequestBuilder)
+var defaultBuilderMutex sync.RWMutex
+
+// DefaultBuilder returns the RequestBuilder used by the package-level functions
+// (Get, Post, ForkJoin, etc.)
+func DefaultBuilder() *RequestBuilder {
+	defaultBuilderMutex.RLock()
+	defer defaultBuilderMutex.RUnlock()
+
+	return defaultBuilder
+}
+
+// SetDefaultBuilder replaces the RequestBuilder used by the package-level functions,
+// so timeouts, headers, retries, etc. can be set once for the whole application.
+//
+//	rest.SetDefaultBuilder(&rest.RequestBuilder{
+//		Timeout: 2 * time.Second,
+//		Headers: headers,
+//	})
+//
+// A nil rb restores a RequestBuilder with default values.
+func SetDefaultBuilder(rb *RequestBuilder) {
+	if rb == nil {
+		rb = new(RequestBuilder)
+	}
+
+	defaultBuilderMutex.Lock()
+	defaultBuilder = rb
+	defaultBuilderMutex.Unlock()
+}
 
 // Get handles a GET HTTP verb to an specified URL.
 //
@@ -10,8 +44,15 @@ var defaultBuilder = RequestBuilder{}
 //
 // Get uses the DefaultBuilder.
 func Get(url string) *Response {
-	//return defaultBuilder.Get(url)
-	return nil
+	return DefaultBuilder().Get(url)
+}
+
+// GetCtx is the context-aware option for Get.
+// The request will be cancelled whenever ctx is done.
+//
+// GetCtx uses the DefaultBuilder.
+func GetCtx(ctx context.Context, url string) *Response {
+	return DefaultBuilder().GetCtx(ctx, url)
 }
 
 // Post handles a POST HTTP verb to an specified URL.
@@ -23,9 +64,18 @@ func Get(url string) *Response {
 // 404(Not Found), 405(Method Not Allowed) or 409(Conflict) if resource already exist.
 //
 // Body could be any of the form: string, []byte, struct & map.
+//
+// Post uses the DefaultBuilder.
 func Post(url string, body interface{}) *Response {
-	//return defaultBuilder.Post(url, body)
-	return nil
+	return DefaultBuilder().Post(url, body)
+}
+
+// PostCtx is the context-aware option for Post.
+// The request will be cancelled whenever ctx is done.
+//
+// PostCtx uses the DefaultBuilder.
+func PostCtx(ctx context.Context, url string, body interface{}) *Response {
+	return DefaultBuilder().PostCtx(ctx, url, body)
 }
 
 // Put handles a PUT HTTP verb to an specified URL
@@ -42,8 +92,15 @@ func Post(url string, body interface{}) *Response {
 //
 // Put uses the DefaultBuilder.
 func Put(url string, body interface{}) *Response {
-	//return defaultBuilder.Put(url, body)
-	return nil
+	return DefaultBuilder().Put(url, body)
+}
+
+// PutCtx is the context-aware option for Put.
+// The request will be cancelled whenever ctx is done.
+//
+// PutCtx uses the DefaultBuilder.
+func PutCtx(ctx context.Context, url string, body interface{}) *Response {
+	return DefaultBuilder().PutCtx(ctx, url, body)
 }
 
 // Patch issues a PATCH HTTP verb to the specified URL
@@ -56,7 +113,15 @@ func Put(url string, body interface{}) *Response {
 //
 // Patch uses the DefaultBuilder.
 func Patch(url string, body interface{}) *Response {
-	return defaultBuilder.Patch(url, body)
+	return DefaultBuilder().Patch(url, body)
+}
+
+// PatchCtx is the context-aware option for Patch.
+// The request will be cancelled whenever ctx is done.
+//
+// PatchCtx uses the DefaultBuilder.
+func PatchCtx(ctx context.Context, url string, body interface{}) *Response {
+	return DefaultBuilder().PatchCtx(ctx, url, body)
 }
 
 // Delete handles a DELETE HTTP verb to an specified URL.
@@ -67,8 +132,15 @@ func Patch(url string, body interface{}) *Response {
 //
 // Delete uses the DefaultBuilder.
 func Delete(url string) *Response {
-	//return defaultBuilder.Delete(url)
-	return nil
+	return DefaultBuilder().Delete(url)
+}
+
+// DeleteCtx is the context-aware option for Delete.
+// The request will be cancelled whenever ctx is done.
+//
+// DeleteCtx uses the DefaultBuilder.
+func DeleteCtx(ctx context.Context, url string) *Response {
+	return DefaultBuilder().DeleteCtx(ctx, url)
 }
 
 // Head issues a HEAD HTTP verb to the specified URL
@@ -79,7 +151,15 @@ func Delete(url string) *Response {
 //
 // Head uses the DefaultBuilder.
 func Head(url string) *Response {
-	return defaultBuilder.Head(url)
+	return DefaultBuilder().Head(url)
+}
+
+// HeadCtx is the context-aware option for Head.
+// The request will be cancelled whenever ctx is done.
+//
+// HeadCtx uses the DefaultBuilder.
+func HeadCtx(ctx context.Context, url string) *Response {
+	return DefaultBuilder().HeadCtx(ctx, url)
 }
 
 // Options issues a OPTIONS HTTP verb to the specified URL
@@ -89,7 +169,15 @@ func Head(url string) *Response {
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
 func Options(url string) *Response {
-	return defaultBuilder.Options(url)
+	return DefaultBuilder().Options(url)
+}
+
+// OptionsCtx is the context-aware option for Options.
+// The request will be cancelled whenever ctx is done.
+//
+// OptionsCtx uses the DefaultBuilder.
+func OptionsCtx(ctx context.Context, url string) *Response {
+	return DefaultBuilder().OptionsCtx(ctx, url)
 }
 
 // AsyncGet is the *asynchronous* option for GET.
@@ -99,7 +187,14 @@ func Options(url string) *Response {
 //
 // AsyncGet uses the DefaultBuilder
 func AsyncGet(url string, f func(*Response)) {
-	defaultBuilder.AsyncGet(url, f)
+	DefaultBuilder().AsyncGet(url, f)
+}
+
+// AsyncGetCtx is the context-aware option for AsyncGet.
+//
+// AsyncGetCtx uses the DefaultBuilder
+func AsyncGetCtx(ctx context.Context, url string, f func(*Response)) {
+	DefaultBuilder().AsyncGetCtx(ctx, url, f)
 }
 
 // AsyncPost is the *asynchronous* option for POST.
@@ -109,7 +204,14 @@ func AsyncGet(url string, f func(*Response)) {
 //
 // AsyncPost uses the DefaultBuilder
 func AsyncPost(url string, body interface{}, f func(*Response)) {
-	defaultBuilder.AsyncPost(url, body, f)
+	DefaultBuilder().AsyncPost(url, body, f)
+}
+
+// AsyncPostCtx is the context-aware option for AsyncPost.
+//
+// AsyncPostCtx uses the DefaultBuilder
+func AsyncPostCtx(ctx context.Context, url string, body interface{}, f func(*Response)) {
+	DefaultBuilder().AsyncPostCtx(ctx, url, body, f)
 }
 
 // AsyncPut is the *asynchronous* option for PUT.
@@ -119,7 +221,14 @@ func AsyncPost(url string, body interface{}, f func(*Response)) {
 //
 // AsyncPut uses the DefaultBuilder
 func AsyncPut(url string, body interface{}, f func(*Response)) {
-	defaultBuilder.AsyncPut(url, body, f)
+	DefaultBuilder().AsyncPut(url, body, f)
+}
+
+// AsyncPutCtx is the context-aware option for AsyncPut.
+//
+// AsyncPutCtx uses the DefaultBuilder
+func AsyncPutCtx(ctx context.Context, url string, body interface{}, f func(*Response)) {
+	DefaultBuilder().AsyncPutCtx(ctx, url, body, f)
 }
 
 // AsyncDelete is the *asynchronous* option for DELETE.
@@ -129,7 +238,14 @@ func AsyncPut(url string, body interface{}, f func(*Response)) {
 //
 // AsyncDelete uses the DefaultBuilder
 func AsyncDelete(url string, f func(*Response)) {
-	defaultBuilder.AsyncDelete(url, f)
+	DefaultBuilder().AsyncDelete(url, f)
+}
+
+// AsyncDeleteCtx is the context-aware option for AsyncDelete.
+//
+// AsyncDeleteCtx uses the DefaultBuilder
+func AsyncDeleteCtx(ctx context.Context, url string, f func(*Response)) {
+	DefaultBuilder().AsyncDeleteCtx(ctx, url, f)
 }
 
 // AsyncPatch is the *asynchronous* option for PATCH.
@@ -139,7 +255,14 @@ func AsyncDelete(url string, f func(*Response)) {
 //
 // AsyncPatch uses the DefaultBuilder
 func AsyncPatch(url string, body interface{}, f func(*Response)) {
-	defaultBuilder.AsyncPatch(url, body, f)
+	DefaultBuilder().AsyncPatch(url, body, f)
+}
+
+// AsyncPatchCtx is the context-aware option for AsyncPatch.
+//
+// AsyncPatchCtx uses the DefaultBuilder
+func AsyncPatchCtx(ctx context.Context, url string, body interface{}, f func(*Response)) {
+	DefaultBuilder().AsyncPatchCtx(ctx, url, body, f)
 }
 
 // AsyncHead is the *asynchronous* option for HEAD.
@@ -149,7 +272,14 @@ func AsyncPatch(url string, body interface{}, f func(*Response)) {
 //
 // AsyncHead uses the DefaultBuilder
 func AsyncHead(url string, f func(*Response)) {
-	defaultBuilder.AsyncHead(url, f)
+	DefaultBuilder().AsyncHead(url, f)
+}
+
+// AsyncHeadCtx is the context-aware option for AsyncHead.
+//
+// AsyncHeadCtx uses the DefaultBuilder
+func AsyncHeadCtx(ctx context.Context, url string, f func(*Response)) {
+	DefaultBuilder().AsyncHeadCtx(ctx, url, f)
 }
 
 // AsyncOptions is the *asynchronous* option for OPTIONS.
@@ -159,11 +289,24 @@ func AsyncHead(url string, f func(*Response)) {
 //
 // AsyncOptions uses the DefaultBuilder
 func AsyncOptions(url string, f func(*Response)) {
-	defaultBuilder.AsyncOptions(url, f)
+	DefaultBuilder().AsyncOptions(url, f)
+}
+
+// AsyncOptionsCtx is the context-aware option for AsyncOptions.
+//
+// AsyncOptionsCtx uses the DefaultBuilder
+func AsyncOptionsCtx(ctx context.Context, url string, f func(*Response)) {
+	DefaultBuilder().AsyncOptionsCtx(ctx, url, f)
 }
 
 // ForkJoin let you *fork* requests, and *wait* until all of them have return.
 //
 func ForkJoin(f func(*Concurrent)) {
-	defaultBuilder.ForkJoin(f)
+	DefaultBuilder().ForkJoin(f)
+}
+
+// ForkJoinCtx is the context-aware option for ForkJoin.
+// Every request forked by the Concurrent will be cancelled whenever ctx is done.
+func ForkJoinCtx(ctx context.Context, f func(*Concurrent)) {
+	DefaultBuilder().ForkJoinCtx(ctx, f)
 }
@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"testing"
@@ -186,6 +188,49 @@ func TestHeaders(t *testing.T) {
 
 }
 
+func TestGetCtxCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := rb.GetCtx(ctx, "/slow/user")
+	if !errors.Is(r.Err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", r.Err)
+	}
+}
+
+func TestPostCtxDeadline(t *testing.T) {
+
+	builder := RequestBuilder{BaseURL: server.URL, DisableTimeout: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Millisecond)
+	defer cancel()
+
+	r := builder.PostCtx(ctx, "/slow/user", &User{Name: "Matilda"})
+	if !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", r.Err)
+	}
+}
+
+func TestSetDefaultBuilder(t *testing.T) {
+
+	defer SetDefaultBuilder(DefaultBuilder())
+
+	h := make(http.Header)
+	h.Add("X-Test", "test")
+
+	SetDefaultBuilder(&RequestBuilder{
+		BaseURL: server.URL,
+		Headers: h,
+	})
+
+	r := Get("/header")
+
+	if r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+}
+
 func TestWrongURL(t *testing.T) {
 	r := Get("foo")
 	if r.Err == nil {
@@ -208,7 +253,7 @@ func TestRequestWithProxyAndFollowRedirect(t *testing.T) {
 	restClient.FollowRedirect = true
 
 	response := restClient.Get(server.URL + "/user")
-	expected := "error connecting to proxy"
+	expected := "proxyconnect"
 
 	if !strings.Contains(response.Err.Error(), expected) {
 		t.Fatalf("Expected %v Error, Got %v as Response", expected, response.Err.Error())
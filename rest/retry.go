@@ -0,0 +1,268 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultRetryBaseDelay is the default initial backoff between attempts.
+var DefaultRetryBaseDelay = 100 * time.Millisecond
+
+// DefaultRetryMaxDelay is the default maximum backoff between attempts.
+var DefaultRetryMaxDelay = 10 * time.Second
+
+// DefaultRetryStatusCodes are the response status codes retried by default.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var idempotentVerbs = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete,
+}
+
+// RetryPolicy defines how a RequestBuilder retries failed requests.
+//
+// Attempts are delayed using an exponential backoff with full jitter.
+// On 429(Too Many Requests) and 503(Service Unavailable), a Retry-After header
+// sent by the server takes precedence over the backoff.
+//
+// Every attempt goes through the middlewares, and the marshalled body is
+// sent again on each one of them.
+type RetryPolicy struct {
+
+	// Maximum number of attempts, counting the first one.
+	// Zero or one means no retries.
+	MaxAttempts int
+
+	// Backoff for the first retry. It doubles on each attempt.
+	// Default: DefaultRetryBaseDelay
+	BaseDelay time.Duration
+
+	// Upper bound for the backoff and the Retry-After header.
+	// Default: DefaultRetryMaxDelay
+	MaxDelay time.Duration
+
+	// Response status codes that should be retried.
+	// Default: DefaultRetryStatusCodes
+	StatusCodes []int
+
+	// RetryOn let you decide if a response should be retried.
+	// When set, it replaces the StatusCodes and network errors checks.
+	RetryOn func(*Response) bool
+
+	// By default only idempotent verbs (GET, HEAD, OPTIONS, PUT & DELETE)
+	// are retried. Set it to also retry POST and PATCH.
+	RetryNonIdempotent bool
+}
+
+// Attempts shows how many times the request was sent to the server.
+func (r *Response) Attempts() int {
+	return r.attempts
+}
+
+// send performs the round trip, retrying according to the RetryPolicy
+func (rb *RequestBuilder) send(client *http.Client, req *http.Request) *Response {
+
+	rt := rb.roundTrip(client)
+	rp := rb.RetryPolicy
+
+	attemptReq := req
+
+	for attempt := 1; ; attempt++ {
+
+		resp := rt(attemptReq)
+		if resp == nil || (resp.Response == nil && resp.Err == nil) {
+			resp = &Response{Err: ErrNoResponse}
+		}
+		resp.attempts = attempt
+
+		if rp == nil || attempt >= rp.MaxAttempts || !rp.shouldRetry(req.Method, resp) {
+			return resp
+		}
+
+		// Rewind the body, or keep this response if it can't be sent again
+		next, ok := rewind(req)
+		if !ok {
+			return resp
+		}
+
+		// Discard the streamed body of the failed attempt
+		resp.Close()
+
+		if !wait(req.Context(), rp.delay(attempt, resp)) {
+			if next.Body != nil {
+				next.Body.Close()
+			}
+			return resp
+		}
+
+		attemptReq = next
+	}
+}
+
+// rewind clones req with a new body, for another attempt.
+// It fails for bodies that can only be sent once.
+func rewind(req *http.Request) (*http.Request, bool) {
+
+	next := req.Clone(req.Context())
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, true
+	}
+
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+
+	next.Body = body
+	return next, true
+}
+
+func (rp *RetryPolicy) shouldRetry(verb string, resp *Response) bool {
+
+	if !rp.RetryNonIdempotent && !matchVerbs(verb, idempotentVerbs) {
+		return false
+	}
+
+	if rp.RetryOn != nil {
+		return rp.RetryOn(resp)
+	}
+
+	if resp.Err != nil {
+		return isRetryableError(resp.Err)
+	}
+
+	if resp.Response == nil {
+		return false
+	}
+
+	statusCodes := rp.StatusCodes
+	if statusCodes == nil {
+		statusCodes = DefaultRetryStatusCodes
+	}
+
+	for _, code := range statusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay returns the time to wait after the given attempt
+func (rp *RetryPolicy) delay(attempt int, resp *Response) time.Duration {
+
+	maxDelay := rp.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	if d, ok := retryAfter(resp); ok {
+		if d > maxDelay {
+			return maxDelay
+		}
+		return d
+	}
+
+	baseDelay := rp.BaseDelay
+	if baseDelay <= 0 {
+		baseDelay = DefaultRetryBaseDelay
+	}
+
+	backoff := maxDelay
+	if shift := uint(attempt - 1); shift < 32 && baseDelay<<shift < maxDelay {
+		backoff = baseDelay << shift
+	}
+
+	// Full jitter
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryAfter parses the Retry-After header of 429 & 503 responses,
+// either in seconds or as an HTTP date
+func retryAfter(resp *Response) (time.Duration, bool) {
+
+	if resp.Response == nil {
+		return 0, false
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+func isRetryableError(err error) bool {
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// *url.Error is itself a net.Error, look at the cause
+	var uErr *url.Error
+	if errors.As(err, &uErr) {
+		err = uErr.Err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// wait sleeps for d, returning false if ctx is done before
+func wait(ctx context.Context, d time.Duration) bool {
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+
+		b, _ := ioutil.ReadAll(req.Body)
+
+		if atomic.AddInt32(&calls, 1) <= failures {
+			writer.WriteHeader(status)
+			return
+		}
+
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Write(b)
+	}))
+
+	return server, &calls
+}
+
+func TestRetryGet(t *testing.T) {
+
+	server, calls := flakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	}
+
+	r := builder.Get("/user")
+
+	if r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if r.Attempts() != 3 || atomic.LoadInt32(calls) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", r.Attempts())
+	}
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+
+	server, _ := flakyServer(5, http.StatusBadGateway)
+	defer server.Close()
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	}
+
+	r := builder.Get("/user")
+
+	if r.StatusCode != http.StatusBadGateway {
+		t.Fatal("Status != Bad Gateway (502)")
+	}
+
+	if r.Attempts() != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", r.Attempts())
+	}
+}
+
+func TestRetryPostNotIdempotent(t *testing.T) {
+
+	server, _ := flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	}
+
+	r := builder.Post("/user", &User{Name: "Matilda"})
+
+	if r.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("Status != Service Unavailable (503)")
+	}
+
+	if r.Attempts() != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", r.Attempts())
+	}
+}
+
+func TestRetryPostRewindsBody(t *testing.T) {
+
+	server, _ := flakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	builder := RequestBuilder{
+		BaseURL: server.URL,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:        3,
+			BaseDelay:          time.Millisecond,
+			RetryNonIdempotent: true,
+		},
+	}
+
+	r := builder.Post("/user", &User{Name: "Matilda"})
+
+	if r.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if r.String() != `{"id":0,"name":"Matilda"}` {
+		t.Fatalf("Body was not rewound, got %s", r.String())
+	}
+}
+
+func TestRetryConnectionError(t *testing.T) {
+
+	server, _ := flakyServer(0, http.StatusOK)
+	server.Close()
+
+	builder := RequestBuilder{
+		BaseURL:     server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	}
+
+	r := builder.Get("/user")
+
+	if r.Err == nil {
+		t.Fatal("Closed server should get an error")
+	}
+
+	if r.Attempts() != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", r.Attempts())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+
+	rp := RetryPolicy{MaxDelay: 5 * time.Second}
+
+	resp := &Response{Response: &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3"}},
+	}}
+
+	if d := rp.delay(1, resp); d != 3*time.Second {
+		t.Fatalf("Expected 3s, got %v", d)
+	}
+
+	resp.Header.Set("Retry-After", "60")
+
+	if d := rp.delay(1, resp); d != 5*time.Second {
+		t.Fatalf("Expected MaxDelay (5s), got %v", d)
+	}
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+
+	if d := rp.delay(1, resp); d != 5*time.Second {
+		t.Fatalf("Expected MaxDelay (5s), got %v", d)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+
+	rp := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	resp := new(Response)
+
+	for attempt, max := range []time.Duration{10, 20, 40, 50, 50} {
+		if d := rp.delay(attempt+1, resp); d < 0 || d > max*time.Millisecond {
+			t.Fatalf("Attempt %d: backoff %v out of range", attempt+1, d)
+		}
+	}
+}
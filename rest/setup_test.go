@@ -13,20 +13,25 @@ import (
 	"time"
 )
 
-var lastModifiedDate = time.Now()
+var lastModifiedDate = time.Now().UTC().Truncate(time.Second)
 
 type User struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
 }
 
+type Users struct {
+	XMLName xml.Name `xml:"users"`
+	Users   []User   `xml:"user"`
+}
+
 var tmux = http.NewServeMux()
 var server = httptest.NewServer(tmux)
 
 var users []User
 
 var userList = []string{
-	"Max", "Dario", "Demian", "Juana", "Andy", "John", "Susy", "Felicitas",
+	"Max", "Dario", "Demian", "Juana", "Andy", "John", "Susy", "Felicitas", "Hernan",
 }
 
 var rb = RequestBuilder{
@@ -113,7 +118,7 @@ func usersCacheWithExpires(writer http.ResponseWriter, req *http.Request) {
 		expires := time.Now().Add(time.Duration(c) * time.Second)
 
 		writer.Header().Set("Content-Type", "application/json")
-		writer.Header().Set("Expires", expires.Format(httpDateFormat))
+		writer.Header().Set("Expires", expires.UTC().Format(httpDateFormat))
 		writer.Write(b)
 	}
 }
@@ -165,7 +170,7 @@ func usersXML(writer http.ResponseWriter, req *http.Request) {
 	// Get
 	if req.Method == http.MethodGet {
 
-		b, _ := xml.Marshal(users)
+		b, _ := xml.Marshal(Users{Users: users})
 
 		writer.Header().Set("Content-Type", "application/xml")
 		writer.Header().Set("Cache-Control", "no-cache")
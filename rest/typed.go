@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrNotCompleted is the error of a Future whose ForkJoin is not completed
+var ErrNotCompleted = errors.New("ForkJoin operation not completed")
+
+// GetAs performs a GET request, and decodes the response into a T.
+//
+// The error is either the transport error, a *HTTPError for non 2xx
+// responses, or the decoding error. Empty responses decode to the zero T.
+// A nil rb means the DefaultBuilder.
+//
+//	user, resp, err := rest.GetAs[User](rb, "/users/1")
+func GetAs[T any](rb *RequestBuilder, url string) (T, *Response, error) {
+	return doAs[T](context.Background(), rb, http.MethodGet, url, nil)
+}
+
+// GetAsCtx is the context-aware option for GetAs.
+func GetAsCtx[T any](ctx context.Context, rb *RequestBuilder, url string) (T, *Response, error) {
+	return doAs[T](ctx, rb, http.MethodGet, url, nil)
+}
+
+// PostAs performs a POST request, and decodes the response into a Resp.
+// The request body type is inferred, so only Resp has to be given:
+//
+//	created, resp, err := rest.PostAs[User](rb, "/users", &User{Name: "Hernan"})
+//
+// See GetAs for the returned error.
+func PostAs[Resp any, Req any](rb *RequestBuilder, url string, body Req) (Resp, *Response, error) {
+	return doAs[Resp](context.Background(), rb, http.MethodPost, url, body)
+}
+
+// PostAsCtx is the context-aware option for PostAs.
+func PostAsCtx[Resp any, Req any](ctx context.Context, rb *RequestBuilder, url string, body Req) (Resp, *Response, error) {
+	return doAs[Resp](ctx, rb, http.MethodPost, url, body)
+}
+
+// PutAs performs a PUT request, and decodes the response into a Resp.
+// See PostAs.
+func PutAs[Resp any, Req any](rb *RequestBuilder, url string, body Req) (Resp, *Response, error) {
+	return doAs[Resp](context.Background(), rb, http.MethodPut, url, body)
+}
+
+// PutAsCtx is the context-aware option for PutAs.
+func PutAsCtx[Resp any, Req any](ctx context.Context, rb *RequestBuilder, url string, body Req) (Resp, *Response, error) {
+	return doAs[Resp](ctx, rb, http.MethodPut, url, body)
+}
+
+// PatchAs performs a PATCH request, and decodes the response into a Resp.
+// See PostAs.
+func PatchAs[Resp any, Req any](rb *RequestBuilder, url string, body Req) (Resp, *Response, error) {
+	return doAs[Resp](context.Background(), rb, http.MethodPatch, url, body)
+}
+
+// PatchAsCtx is the context-aware option for PatchAs.
+func PatchAsCtx[Resp any, Req any](ctx context.Context, rb *RequestBuilder, url string, body Req) (Resp, *Response, error) {
+	return doAs[Resp](ctx, rb, http.MethodPatch, url, body)
+}
+
+// DeleteAs performs a DELETE request, and decodes the response into a T.
+// See GetAs.
+func DeleteAs[T any](rb *RequestBuilder, url string) (T, *Response, error) {
+	return doAs[T](context.Background(), rb, http.MethodDelete, url, nil)
+}
+
+// DeleteAsCtx is the context-aware option for DeleteAs.
+func DeleteAsCtx[T any](ctx context.Context, rb *RequestBuilder, url string) (T, *Response, error) {
+	return doAs[T](ctx, rb, http.MethodDelete, url, nil)
+}
+
+// Future is a typed FutureResponse, whose result is decoded into a T.
+//
+//	var user *rest.Future[User]
+//
+//	rb.ForkJoin(func(c *rest.Concurrent) {
+//		user = rest.GetFuture[User](c, "/users/1")
+//	})
+//
+//	u, resp, err := user.Result()
+type Future[T any] struct {
+	fr  *FutureResponse
+	url string
+}
+
+// Response gives you the Response of the request, nil until the
+// ForkJoin operation is completed.
+func (f *Future[T]) Response() *Response {
+	return f.fr.Response()
+}
+
+// Result returns the decoded response, as GetAs does.
+// Until the ForkJoin operation is completed, the error is ErrNotCompleted.
+func (f *Future[T]) Result() (T, *Response, error) {
+
+	resp := f.fr.Response()
+	if resp == nil {
+		var zero T
+		return zero, nil, ErrNotCompleted
+	}
+
+	out, err := decodeAs[T](resp, f.url)
+	return out, resp, err
+}
+
+// GetFuture performs a GET request concurrently, see Future.
+func GetFuture[T any](c *Concurrent, url string) *Future[T] {
+	return newFuture[T](c, url, c.Get(url))
+}
+
+// PostFuture performs a POST request concurrently, see Future.
+func PostFuture[Resp any, Req any](c *Concurrent, url string, body Req) *Future[Resp] {
+	return newFuture[Resp](c, url, c.Post(url, body))
+}
+
+// PutFuture performs a PUT request concurrently, see Future.
+func PutFuture[Resp any, Req any](c *Concurrent, url string, body Req) *Future[Resp] {
+	return newFuture[Resp](c, url, c.Put(url, body))
+}
+
+// PatchFuture performs a PATCH request concurrently, see Future.
+func PatchFuture[Resp any, Req any](c *Concurrent, url string, body Req) *Future[Resp] {
+	return newFuture[Resp](c, url, c.Patch(url, body))
+}
+
+// DeleteFuture performs a DELETE request concurrently, see Future.
+func DeleteFuture[T any](c *Concurrent, url string) *Future[T] {
+	return newFuture[T](c, url, c.Delete(url))
+}
+
+func newFuture[T any](c *Concurrent, url string, fr *FutureResponse) *Future[T] {
+	return &Future[T]{fr: fr, url: c.reqBuilder.BaseURL + url}
+}
+
+func doAs[T any](ctx context.Context, rb *RequestBuilder, verb string, url string, body interface{}) (T, *Response, error) {
+
+	if rb == nil {
+		rb = DefaultBuilder()
+	}
+
+	resp := rb.doRequest(ctx, verb, url, body)
+	out, err := decodeAs[T](resp, rb.BaseURL+url)
+
+	return out, resp, err
+}
+
+// decodeAs combines the transport error, the status & the decoding of resp
+func decodeAs[T any](resp *Response, reqURL string) (T, error) {
+
+	var out T
+
+	if resp.Err != nil {
+		return out, resp.Err
+	}
+
+	// Read streamed responses, so they can be decoded
+	if resp.streamed {
+		if err := resp.readStream(); err != nil {
+			return out, err
+		}
+	}
+
+	if !resp.IsSuccess() {
+		return out, newHTTPError(resp, reqURL)
+	}
+
+	if len(resp.byteBody) == 0 {
+		return out, nil
+	}
+
+	err := resp.FillUp(&out)
+	return out, err
+}
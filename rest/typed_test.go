@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func newTypedMockServer(t *testing.T) *MockServer {
+
+	ms := NewMockServer()
+	t.Cleanup(ms.Close)
+
+	json := http.Header{"Content-Type": {"application/json"}}
+
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  json,
+		RespBody:     `{"id":1,"name":"Max"}`,
+	}, &Mock{
+		URL:          "http://mytest.com/users/2",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+	}, &Mock{
+		URL:          "http://mytest.com/users/3",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  json,
+		RespBody:     `{"id":"three"}`,
+	}, &Mock{
+		URL:          "http://mytest.com/users",
+		HTTPMethod:   http.MethodPost,
+		ReqBody:      `{"id":0,"name":"Hernan"}`,
+		RespHTTPCode: http.StatusCreated,
+		RespHeaders:  json,
+		RespBody:     `{"id":9,"name":"Hernan"}`,
+	}, &Mock{
+		URL:          "http://mytest.com/users/1",
+		HTTPMethod:   http.MethodDelete,
+		RespHTTPCode: http.StatusNoContent,
+	})
+
+	return ms
+}
+
+func TestGetAs(t *testing.T) {
+
+	rb := &RequestBuilder{MockServer: newTypedMockServer(t), BaseURL: "http://mytest.com"}
+
+	u, resp, err := GetAs[User](rb, "/users/1")
+	if err != nil || u.Name != "Max" || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected user %+v, error: %v", u, err)
+	}
+
+	var httpErr *HTTPError
+	if _, _, err := GetAs[User](rb, "/users/2"); !errors.As(err, &httpErr) || httpErr.URL != "http://mytest.com/users/2" {
+		t.Fatalf("Expected an HTTPError, got %v", err)
+	}
+
+	if _, _, err := GetAs[User](rb, "/users/3"); err == nil {
+		t.Fatal("Should get a decoding error")
+	}
+
+	created, resp, err := PostAs[User](rb, "/users", User{Name: "Hernan"})
+	if err != nil || created.ID != 9 || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Unexpected user %+v, error: %v", created, err)
+	}
+
+	if _, resp, err := DeleteAs[struct{}](rb, "/users/1"); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// Streamed responses are read to be decoded
+	rb.Stream = true
+	if u, _, err := GetAs[map[string]interface{}](rb, "/users/1"); err != nil || u["name"] != "Max" {
+		t.Fatalf("Unexpected user %v, error: %v", u, err)
+	}
+}
+
+func TestFuture(t *testing.T) {
+
+	rb := &RequestBuilder{MockServer: newTypedMockServer(t), BaseURL: "http://mytest.com"}
+
+	var user, missing *Future[User]
+	var created *Future[User]
+
+	rb.ForkJoin(func(c *Concurrent) {
+		user = GetFuture[User](c, "/users/1")
+		missing = GetFuture[User](c, "/users/2")
+		created = PostFuture[User](c, "/users", &User{Name: "Hernan"})
+
+		if _, _, err := user.Result(); err != ErrNotCompleted {
+			t.Errorf("Expected ErrNotCompleted, got %v", err)
+		}
+	})
+
+	if u, _, err := user.Result(); err != nil || u.Name != "Max" {
+		t.Fatalf("Unexpected user %+v, error: %v", u, err)
+	}
+
+	if _, resp, err := missing.Result(); err == nil || !resp.IsClientError() {
+		t.Fatalf("Expected an error, got %v", err)
+	}
+
+	if u, _, err := created.Result(); err != nil || u.ID != 9 {
+		t.Fatalf("Unexpected user %+v, error: %v", u, err)
+	}
+}
@@ -0,0 +1,658 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var yamlInt = regexp.MustCompile(`^[-+]?[0-9]+$`)
+var yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+
+// yamlToJSON converts a YAML document to JSON.
+//
+// It reads the YAML fixtures are written in: block mappings & sequences,
+// plain & quoted scalars, literal (|) & folded (>) block scalars,
+// single line flow collections ([a, b] & {a: b}) and comments.
+// Anchors, aliases, tags & multiple documents are not supported.
+func yamlToJSON(b []byte) ([]byte, error) {
+
+	text := strings.TrimSuffix(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	p := &yamlParser{lines: strings.Split(text, "\n")}
+
+	v, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+func (p *yamlParser) errorf(line int, format string, a ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", line+1, fmt.Sprintf(format, a...))
+}
+
+func (p *yamlParser) document() (interface{}, error) {
+
+	indent, err := p.next()
+	if err != nil || indent < 0 {
+		return nil, err
+	}
+
+	// Document start marker
+	if stripYAMLComment(p.lines[p.pos]) == "---" {
+		p.pos++
+		if indent, err = p.next(); err != nil || indent < 0 {
+			return nil, err
+		}
+	}
+
+	v, err := p.node(indent, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	if indent, err = p.next(); err != nil {
+		return nil, err
+	}
+
+	// Document end marker
+	if indent >= 0 && stripYAMLComment(p.lines[p.pos]) == "..." {
+		p.pos++
+		if indent, err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if indent >= 0 {
+		return nil, p.errorf(p.pos, "unexpected content, check the indentation (multiple documents are not supported)")
+	}
+
+	return v, nil
+}
+
+// next skips blank & comment lines, and returns the indentation
+// of the next line, or -1 at the end of the document.
+func (p *yamlParser) next() (int, error) {
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		line := p.lines[p.pos]
+		content := strings.TrimLeft(line, " ")
+
+		if strings.TrimSpace(content) == "" || content[0] == '#' {
+			continue
+		}
+
+		if content[0] == '\t' {
+			return 0, p.errorf(p.pos, "tabs are not allowed for indentation")
+		}
+
+		return len(line) - len(content), nil
+	}
+
+	return -1, nil
+}
+
+// node reads the node starting at the current line, indented by indent.
+// Block scalar lines must be more indented than parent.
+func (p *yamlParser) node(indent int, parent int) (interface{}, error) {
+
+	content := p.lines[p.pos][indent:]
+
+	if isYAMLSeqItem(content) {
+		return p.sequence(indent)
+	}
+
+	if _, _, ok, err := splitYAMLKey(content); err != nil {
+		return nil, p.errorf(p.pos, "%s", err.Error())
+	} else if ok {
+		return p.mapping(indent)
+	}
+
+	return p.scalar(stripYAMLComment(content), parent)
+}
+
+// scalar reads a value written after a key or sequence dash,
+// or on its own line.
+func (p *yamlParser) scalar(s string, parent int) (interface{}, error) {
+
+	line := p.pos
+	p.pos++
+
+	if s != "" && (s[0] == '|' || s[0] == '>') {
+		v, err := p.blockScalar(s, parent)
+		if err != nil {
+			return nil, p.errorf(line, "%s", err.Error())
+		}
+		return v, nil
+	}
+
+	v, err := parseYAMLInline(s)
+	if err != nil {
+		return nil, p.errorf(line, "%s", err.Error())
+	}
+
+	return v, nil
+}
+
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+
+	seq := []interface{}{}
+
+	for {
+		next, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if next != indent || !isYAMLSeqItem(p.lines[p.pos][indent:]) {
+			return seq, nil
+		}
+
+		rest := p.lines[p.pos][indent+1:]
+		item := strings.TrimLeft(rest, " ")
+
+		var v interface{}
+
+		if item == "" || item[0] == '#' {
+			// The item is on the next lines
+			p.pos++
+			v, err = p.child(indent)
+		} else {
+			// The item starts right after the dash, as if it was on its own line
+			itemIndent := indent + 1 + len(rest) - len(item)
+			p.lines[p.pos] = strings.Repeat(" ", itemIndent) + item
+			v, err = p.node(itemIndent, indent)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		seq = append(seq, v)
+	}
+}
+
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+
+	m := make(map[string]interface{})
+
+	for {
+		next, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if next < indent {
+			return m, nil
+		}
+
+		if next > indent {
+			return nil, p.errorf(p.pos, "bad indentation")
+		}
+
+		line := p.pos
+		key, rest, ok, err := splitYAMLKey(p.lines[p.pos][indent:])
+		if err != nil {
+			return nil, p.errorf(line, "%s", err.Error())
+		}
+
+		if !ok {
+			// A sequence at the mapping level ends it, if it is the value of a key,
+			// as does a document marker
+			if isYAMLSeqItem(p.lines[p.pos][indent:]) || isYAMLMarker(p.lines[p.pos]) {
+				return m, nil
+			}
+			return nil, p.errorf(line, "expected a key")
+		}
+
+		if _, found := m[key]; found {
+			return nil, p.errorf(line, "duplicated key %q", key)
+		}
+
+		var v interface{}
+
+		if rest = stripYAMLComment(rest); rest != "" {
+			v, err = p.scalar(rest, indent)
+		} else {
+			p.pos++
+			v, err = p.keyValue(indent)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = v
+	}
+}
+
+// keyValue reads the value of a key written on the next lines.
+// Sequences may be as indented as their key.
+func (p *yamlParser) keyValue(indent int) (interface{}, error) {
+
+	next, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if next == indent && isYAMLSeqItem(p.lines[p.pos][indent:]) {
+		return p.sequence(indent)
+	}
+
+	return p.child(indent)
+}
+
+// child reads the node in the next lines, if more indented than parent.
+func (p *yamlParser) child(parent int) (interface{}, error) {
+
+	next, err := p.next()
+	if err != nil || next <= parent {
+		return nil, err
+	}
+
+	return p.node(next, parent)
+}
+
+// blockScalar reads the lines of a literal (|) or folded (>) scalar,
+// which must be more indented than parent.
+func (p *yamlParser) blockScalar(header string, parent int) (string, error) {
+
+	style, chomping := header[0], header[1:]
+	if chomping != "" && chomping != "-" && chomping != "+" {
+		return "", fmt.Errorf("unsupported block scalar header %q", header)
+	}
+
+	var lines []string
+	indent := -1
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		line := p.lines[p.pos]
+		content := strings.TrimLeft(line, " ")
+
+		if content == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		lineIndent := len(line) - len(content)
+
+		if indent < 0 {
+			if lineIndent <= parent {
+				break
+			}
+			indent = lineIndent
+		}
+
+		if lineIndent < indent {
+			break
+		}
+
+		lines = append(lines, line[indent:])
+	}
+
+	n := len(lines)
+	for n > 0 && lines[n-1] == "" {
+		n--
+	}
+
+	trailing := len(lines) - n
+	lines = lines[:n]
+
+	var text string
+
+	if style == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		text = foldYAMLLines(lines)
+	}
+
+	if n > 0 {
+		switch chomping {
+		case "-":
+		case "+":
+			text += strings.Repeat("\n", trailing+1)
+		default:
+			text += "\n"
+		}
+	}
+
+	return text, nil
+}
+
+// foldYAMLLines joins lines with spaces, but for empty & more indented lines
+func foldYAMLLines(lines []string) string {
+
+	var b strings.Builder
+
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			prevFolds := prev != "" && prev[0] != ' '
+
+			switch {
+			case prevFolds && l != "" && l[0] != ' ':
+				b.WriteByte(' ')
+			case prevFolds && l == "":
+				// The first empty line replaces the line break
+			default:
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(l)
+	}
+
+	return b.String()
+}
+
+func isYAMLMarker(line string) bool {
+	line = stripYAMLComment(line)
+	return line == "---" || line == "..."
+}
+
+func isYAMLSeqItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// splitYAMLKey splits a "key: value" line. ok is false if it is not one.
+func splitYAMLKey(content string) (key string, rest string, ok bool, err error) {
+
+	switch content[0] {
+	case '"', '\'':
+		key, n, err := scanYAMLQuoted(content)
+		if err != nil {
+			return "", "", false, err
+		}
+
+		after := strings.TrimLeft(content[n:], " ")
+		if after == "" || after[0] != ':' || (len(after) > 1 && after[1] != ' ') {
+			return "", "", false, nil
+		}
+
+		return key, after[1:], true, nil
+
+	case '[', '{', '-', '#', '|', '>':
+		return "", "", false, nil
+	}
+
+	for i := 0; i < len(content); i++ {
+		switch {
+		case content[i] == '#' && i > 0 && content[i-1] == ' ':
+			return "", "", false, nil
+		case content[i] == ':' && (i+1 == len(content) || content[i+1] == ' '):
+			key = strings.TrimRight(content[:i], " ")
+			return key, content[i+1:], key != "", nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// stripYAMLComment removes a trailing comment & spaces, leaving quoted # alone
+func stripYAMLComment(s string) string {
+
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+
+		c := s[i]
+
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{,:", s[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+
+	return strings.TrimSpace(s)
+}
+
+// parseYAMLInline parses a value written in a single line
+func parseYAMLInline(s string) (interface{}, error) {
+
+	if s == "" {
+		return nil, nil
+	}
+
+	switch s[0] {
+	case '[', '{':
+		f := &yamlFlow{s: s}
+
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+
+		if f.spaces(); f.i < len(s) {
+			return nil, fmt.Errorf("unexpected %q after flow collection", s[f.i:])
+		}
+
+		return v, nil
+
+	case '"', '\'':
+		v, n, err := scanYAMLQuoted(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if n != len(s) {
+			return nil, fmt.Errorf("unexpected %q after quoted scalar", s[n:])
+		}
+
+		return v, nil
+
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	}
+
+	return plainYAMLScalar(s), nil
+}
+
+// scanYAMLQuoted reads the quoted scalar at the start of s,
+// returning its value and length.
+func scanYAMLQuoted(s string) (string, int, error) {
+
+	if s[0] == '\'' {
+		var b strings.Builder
+
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+
+			return b.String(), i + 1, nil
+		}
+
+		return "", 0, errors.New("unterminated quoted scalar")
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("bad quoted scalar %s", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+
+	return "", 0, errors.New("unterminated quoted scalar")
+}
+
+// plainYAMLScalar resolves null, booleans & numbers, following the YAML core schema.
+// Numbers are kept as written.
+func plainYAMLScalar(s string) interface{} {
+
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if yamlInt.MatchString(s) {
+		digits := strings.TrimLeft(strings.TrimLeft(s, "+-"), "0")
+		if digits == "" {
+			digits = "0"
+		}
+		if strings.HasPrefix(s, "-") {
+			digits = "-" + digits
+		}
+		return json.Number(digits)
+	}
+
+	if yamlFloat.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	}
+
+	return s
+}
+
+// yamlFlow reads a single line flow collection
+type yamlFlow struct {
+	s string
+	i int
+}
+
+func (f *yamlFlow) spaces() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *yamlFlow) at(c byte) bool {
+	f.spaces()
+	return f.i < len(f.s) && f.s[f.i] == c
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+
+	f.spaces()
+
+	if f.i >= len(f.s) {
+		return nil, errors.New("unterminated flow collection")
+	}
+
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		seq := []interface{}{}
+
+		for !f.at(']') {
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+
+			if !f.at(',') {
+				break
+			}
+			f.i++
+		}
+
+		if !f.at(']') {
+			return nil, errors.New("expected , or ] in flow sequence")
+		}
+		f.i++
+
+		return seq, nil
+
+	case '{':
+		f.i++
+		m := make(map[string]interface{})
+
+		for !f.at('}') {
+			key, err := f.key()
+			if err != nil {
+				return nil, err
+			}
+
+			if !f.at(':') {
+				return nil, fmt.Errorf("expected : after key %q in flow mapping", key)
+			}
+			f.i++
+
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+
+			if !f.at(',') {
+				break
+			}
+			f.i++
+		}
+
+		if !f.at('}') {
+			return nil, errors.New("expected , or } in flow mapping")
+		}
+		f.i++
+
+		return m, nil
+
+	case '"', '\'':
+		v, n, err := scanYAMLQuoted(f.s[f.i:])
+		f.i += n
+		return v, err
+	}
+
+	start := f.i
+	for f.i < len(f.s) && strings.IndexByte(",[]{}", f.s[f.i]) < 0 {
+		f.i++
+	}
+
+	return parseYAMLInline(strings.TrimSpace(f.s[start:f.i]))
+}
+
+func (f *yamlFlow) key() (string, error) {
+
+	f.spaces()
+
+	if f.i < len(f.s) && (f.s[f.i] == '"' || f.s[f.i] == '\'') {
+		k, n, err := scanYAMLQuoted(f.s[f.i:])
+		f.i += n
+		return k, err
+	}
+
+	start := f.i
+	for f.i < len(f.s) && f.s[f.i] != ':' && strings.IndexByte(",[]{}", f.s[f.i]) < 0 {
+		f.i++
+	}
+
+	return strings.TrimSpace(f.s[start:f.i]), nil
+}
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"", `null`},
+		{"# only a comment\n", `null`},
+		{"---\na: 1\n...\n", `{"a":1}`},
+		{"a: 1\nb: -02\nc: 1.50\nd: true\ne: ~\nf:\ng: text # comment\n", `{"a":1,"b":-2,"c":1.5,"d":true,"e":null,"f":null,"g":"text"}`},
+		{"big: 12345678901234567890\nversion: 1.2.3\n", `{"big":12345678901234567890,"version":"1.2.3"}`},
+		{"url: http://api.com/a?b=c#d\n", `{"url":"http://api.com/a?b=c#d"}`},
+		{`a: "x: \"y\" # z"` + "\nb: 'it''s # here'\n'c d': 1\n", `{"a":"x: \"y\" # z","b":"it's # here","c d":1}`},
+		{"- a\n- 1\n-\n  - b\n- - c\n  - d\n", `["a",1,["b"],["c","d"]]`},
+		{"- url: x\n  headers:\n    A: 1\n    B: [x, 'y, z']\n- url: y\n  list:\n  - 1\n  - {k: v, n: 2}\n", `[{"url":"x","headers":{"A":1,"B":["x","y, z"]}},{"url":"y","list":[1,{"k":"v","n":2}]}]`},
+		{"a: []\nb: {}\nc: [1, [2, 3], ]\n", `{"a":[],"b":{},"c":[1,[2,3]]}`},
+		{"a: |\n  line 1\n    line 2\n\nb: x\n", `{"a":"line 1\n  line 2\n","b":"x"}`},
+		{"a: |-\n  text\n", `{"a":"text"}`},
+		{"a: |+\n  text\n\n", `{"a":"text\n\n"}`},
+		{"a: >\n  folded\n  text\n\n  new\n", `{"a":"folded text\nnew\n"}`},
+		{"- |\n  {\"id\": 1}\n- b\n", `["{\"id\": 1}\n","b"]`},
+	}
+
+	for _, test := range tests {
+		b, err := yamlToJSON([]byte(test.yaml))
+		if err != nil {
+			t.Fatalf("%q: %v", test.yaml, err)
+		}
+
+		var got, expected interface{}
+		json.Unmarshal(b, &got)
+		json.Unmarshal([]byte(test.expected), &expected)
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%q: expected %s, got %s", test.yaml, test.expected, b)
+		}
+	}
+}
+
+func TestYAMLToJSONErrors(t *testing.T) {
+
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"a: 1\n  b: 2\n", "line 2: bad indentation"},
+		{"a: 1\na: 2\n", `line 2: duplicated key "a"`},
+		{"a:\n\tb: 1\n", "line 2: tabs are not allowed"},
+		{"a: &anchor 1\n", "line 1: anchors, aliases and tags are not supported"},
+		{"a: 'open\n", "line 1: unterminated quoted scalar"},
+		{"a: [1, 2\n", "line 1: expected , or ]"},
+		{"a: 1\n---\nb: 2\n", "line 2: unexpected content"},
+		{"- a\nb: 1\n", "line 2: unexpected content"},
+	}
+
+	for _, test := range tests {
+		_, err := yamlToJSON([]byte(test.yaml))
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Fatalf("%q: expected %q, got %v", test.yaml, test.expected, err)
+		}
+	}
+}